@@ -0,0 +1,34 @@
+package analytics
+
+import (
+	"fmt"
+)
+
+func ExampleNetwork_SweepCut() {
+	// Two triangles joined by a single edge.
+	n := &Network{Adj: make(map[uint64]map[uint64]float64)}
+	for _, e := range [][2]uint64{{1, 2}, {2, 3}, {3, 1}, {4, 5}, {5, 6}, {6, 4}, {3, 4}} {
+		for _, u := range e {
+			if n.Adj[u] == nil {
+				n.Adj[u] = make(map[uint64]float64)
+			}
+		}
+		n.Adj[e[0]][e[1]] = 1
+		n.Adj[e[1]][e[0]] = 1
+	}
+	ppr := n.PersonalizedPageRank([]uint64{1}, 0.85, 1e-6)
+	members, conductance := n.SweepCut(ppr)
+	fmt.Printf("%v %.3f\n", members, conductance)
+	// Output:
+	// [1 2 3] 0.143
+}
+
+func ExampleOptions_Validate() {
+	for _, opts := range []Options{DefaultOptions, {Alpha: 1, Epsilon: 1e-4}, {Alpha: 0.85, Epsilon: 0}} {
+		fmt.Println(opts.Validate())
+	}
+	// Output:
+	// <nil>
+	// alpha must be between 0 and 1, got 1
+	// epsilon must be positive, got 0
+}
@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Options for community detection.
+type Options struct {
+	Seeds   int     // number of seed nodes to expand.
+	Alpha   float64 // probability of following an edge in PageRank.
+	Epsilon float64 // PageRank accuracy.
+}
+
+// DefaultOptions are those of Whang, Gleich & Dhillon (2013).
+var DefaultOptions = Options{
+	Seeds:   10,
+	Alpha:   0.99,
+	Epsilon: 1e-4,
+}
+
+// Validate checks alpha is in (0, 1) and epsilon is positive; otherwise
+// PageRank would never converge.
+func (o Options) Validate() error {
+	if o.Alpha <= 0 || o.Alpha >= 1 {
+		return fmt.Errorf("alpha must be between 0 and 1, got %v", o.Alpha)
+	}
+	if o.Epsilon <= 0 {
+		return fmt.Errorf("epsilon must be positive, got %v", o.Epsilon)
+	}
+	return nil
+}
+
+// Community is a set of nodes found by expanding a seed. Samples maps each
+// sample index to the number of members containing it.
+type Community struct {
+	Seed        uint64
+	Members     []uint64
+	Conductance float64
+	Samples     map[int]int
+}
+
+// Communities finds overlapping communities in four steps: tips are filtered
+// out, seeds are picked as spread hubs, each seed's neighbourhood is expanded
+// by personalized PageRank and a sweep cut, and tips are propagated back into
+// the communities of their neighbours. Invalid options return an error.
+func (n *Network) Communities(opts Options) ([]*Community, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	// 1. Filtering.
+	core := &Network{
+		Adj:     make(map[uint64]map[uint64]float64),
+		Colours: n.Colours,
+	}
+	var tips []uint64
+	for _, u := range n.Nodes() {
+		if len(n.Adj[u]) <= 1 {
+			tips = append(tips, u)
+			continue
+		}
+		core.Adj[u] = make(map[uint64]float64)
+	}
+	for u := range core.Adj {
+		for v, w := range n.Adj[u] {
+			if _, ok := core.Adj[v]; ok {
+				core.Adj[u][v] = w
+			}
+		}
+	}
+
+	// 2. Seeding.
+	seeds := core.spreadHubs(opts.Seeds)
+
+	// 3. Seed set expansion.
+	var communities []*Community
+	for _, seed := range seeds {
+		set := []uint64{seed}
+		for v := range core.Adj[seed] {
+			set = append(set, v)
+		}
+		members, conductance := core.SweepCut(core.PersonalizedPageRank(set, opts.Alpha, opts.Epsilon))
+		if len(members) == 0 {
+			continue
+		}
+		communities = append(communities, &Community{
+			Seed:        seed,
+			Members:     members,
+			Conductance: conductance,
+		})
+	}
+
+	// 4. Propagation.
+	for _, c := range communities {
+		in := make(map[uint64]bool, len(c.Members))
+		for _, u := range c.Members {
+			in[u] = true
+		}
+		for _, tip := range tips {
+			for v := range n.Adj[tip] {
+				if in[v] && !in[tip] {
+					in[tip] = true
+					c.Members = append(c.Members, tip)
+				}
+			}
+		}
+		sort.Slice(c.Members, func(i, j int) bool { return c.Members[i] < c.Members[j] })
+		c.Samples = make(map[int]int)
+		for _, u := range c.Members {
+			for _, s := range n.Colours[u].Indices() {
+				c.Samples[s]++
+			}
+		}
+	}
+	return communities, nil
+}
+
+// spreadHubs picks up to k of the highest degree nodes such that no two are
+// neighbours.
+func (n *Network) spreadHubs(k int) []uint64 {
+	nodes := n.Nodes()
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return n.Degree(nodes[i]) > n.Degree(nodes[j])
+	})
+	var seeds []uint64
+	blocked := make(map[uint64]bool)
+	for _, u := range nodes {
+		if len(seeds) == k {
+			break
+		}
+		if blocked[u] {
+			continue
+		}
+		seeds = append(seeds, u)
+		blocked[u] = true
+		for v := range n.Adj[u] {
+			blocked[v] = true
+		}
+	}
+	return seeds
+}
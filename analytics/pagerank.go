@@ -0,0 +1,168 @@
+// Package analytics runs graph algorithms over the pangenome: personalized
+// PageRank and overlapping community detection by seed set expansion, following
+// Whang, Gleich & Dhillon (2013).
+package analytics
+
+import (
+	"sort"
+
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// Network is an undirected weighted view of the pangenome where every node is
+// a unitig and edge weights are the number of contig paths crossing between
+// two unitigs in either direction.
+type Network struct {
+	Adj     map[uint64]map[uint64]float64
+	Colours map[uint64]pangenome.Colours
+}
+
+// NewNetwork builds a network over unitigs. Use idx.NodeUnitigs() to work on
+// single k-mers.
+func NewNetwork(idx *pangenome.Index, unitigs []*pangenome.Unitig) *Network {
+	n := &Network{
+		Adj:     make(map[uint64]map[uint64]float64, len(unitigs)),
+		Colours: make(map[uint64]pangenome.Colours, len(unitigs)),
+	}
+	first := make(map[uint64]uint64, len(unitigs))
+	for _, u := range unitigs {
+		first[u.Members[0]] = u.ID
+		n.Adj[u.ID] = make(map[uint64]float64)
+		n.Colours[u.ID] = u.Colours
+	}
+	for _, u := range unitigs {
+		last := u.Members[len(u.Members)-1]
+		for dst, w := range idx.Forward[last] {
+			v, ok := first[dst]
+			if !ok || v == u.ID {
+				continue
+			}
+			n.Adj[u.ID][v] += float64(w)
+			n.Adj[v][u.ID] += float64(w)
+		}
+	}
+	return n
+}
+
+// Nodes returns every node, sorted.
+func (n *Network) Nodes() []uint64 {
+	nodes := make([]uint64, 0, len(n.Adj))
+	for u := range n.Adj {
+		nodes = append(nodes, u)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes
+}
+
+// Degree returns the total weight of the edges at u.
+func (n *Network) Degree(u uint64) float64 {
+	d := 0.0
+	for _, w := range n.Adj[u] {
+		d += w
+	}
+	return d
+}
+
+// Volume returns the total degree of the network.
+func (n *Network) Volume() float64 {
+	vol := 0.0
+	for u := range n.Adj {
+		vol += n.Degree(u)
+	}
+	return vol
+}
+
+// PersonalizedPageRank approximates the PageRank vector teleporting back to
+// the seeds, using the push method of Andersen, Chung & Lang (2006). Alpha is
+// the probability of following an edge rather than teleporting, and eps bounds
+// the residual left at each node relative to its degree.
+func (n *Network) PersonalizedPageRank(seeds []uint64, alpha float64, eps float64) map[uint64]float64 {
+	p := make(map[uint64]float64)
+	r := make(map[uint64]float64)
+	var queue []uint64
+	queued := make(map[uint64]bool)
+	for _, s := range seeds {
+		r[s] += 1 / float64(len(seeds))
+	}
+	for _, s := range seeds {
+		if !queued[s] {
+			queued[s] = true
+			queue = append(queue, s)
+		}
+	}
+
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		queued[u] = false
+
+		ru := r[u]
+		p[u] += (1 - alpha) * ru
+		r[u] = 0
+		d := n.Degree(u)
+		if d == 0 {
+			// Nowhere to go: keep the mass at u.
+			p[u] += alpha * ru
+			continue
+		}
+		for v, w := range n.Adj[u] {
+			r[v] += alpha * ru * w / d
+			if !queued[v] && r[v] >= eps*n.Degree(v) {
+				queued[v] = true
+				queue = append(queue, v)
+			}
+		}
+	}
+	return p
+}
+
+// SweepCut orders the nodes of a PageRank vector by degree-normalised score
+// and returns the prefix with the lowest conductance, along with it.
+func (n *Network) SweepCut(ppr map[uint64]float64) ([]uint64, float64) {
+	order := make([]uint64, 0, len(ppr))
+	for u, score := range ppr {
+		if score > 0 && n.Degree(u) > 0 {
+			order = append(order, u)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		si, sj := ppr[order[i]]/n.Degree(order[i]), ppr[order[j]]/n.Degree(order[j])
+		if si != sj {
+			return si > sj
+		}
+		return order[i] < order[j]
+	})
+
+	total := n.Volume()
+	in := make(map[uint64]bool)
+	cut, vol := 0.0, 0.0
+	best, bestSize := 1.0, 0
+	for i, u := range order {
+		in[u] = true
+		vol += n.Degree(u)
+		for v, w := range n.Adj[u] {
+			if v == u {
+				continue
+			}
+			if in[v] {
+				cut -= w
+			} else {
+				cut += w
+			}
+		}
+		denom := vol
+		if total-vol < denom {
+			denom = total - vol
+		}
+		if denom <= 0 {
+			break
+		}
+		if c := cut / denom; c < best {
+			best, bestSize = c, i+1
+		}
+	}
+	if bestSize == 0 {
+		return nil, 1
+	}
+	return order[:bestSize], best
+}
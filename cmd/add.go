@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <genome>...",
+	Short: "Add genomes to the pangenome",
+	Long: `Adds every k-mer and edge of each genome to the pangenome. Each
+					genome is stored as a sample named after its file, without
+					the extension. Genomes are read as FASTA, or as GFF3 (with
+					a ##FASTA section) or GenBank by their extension, in which
+					case their features are attached to the nodes they cover.
+					A MinHash sketch of each genome is stored for dist.
+					Up to --workers genomes are read at once. On SIGINT or
+					SIGTERM the current genome is abandoned, leaving the
+					samples already added.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+		contextMain, cancel := interruptContext()
+		defer cancel()
+
+		genomes := readGenomes(args, viper.GetInt("workers"))
+		for _, genome := range args {
+			name := sampleName(genome)
+			r := <-genomes
+			err := r.err
+			if err != nil {
+				return err
+			}
+			if r.a != nil {
+				_, err = g.AddAnnotated(name, r.a, contextMain)
+			} else {
+				r.km.K = g.K
+				_, err = g.AddGenome(name, r.km, contextMain)
+			}
+			if interrupted(contextMain, err, "Samples added before "+name+" are kept; add the rest again.") {
+				g.Close()
+				os.Exit(130)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Println("Added sample:", name)
+		}
+		return nil
+	},
+}
+
+// readGenome is a genome file read by readGenomes: a is set for annotated
+// formats and km otherwise.
+type readGenome struct {
+	a   *pangenome.Annotated
+	km  *kmers.Kmers
+	err error
+}
+
+// readGenomes reads and parses genome files with up to workers at once,
+// sending them on in the order given. At most workers genomes are held before
+// they're received, so memory stays bounded while the graph is written.
+func readGenomes(files []string, workers int) <-chan readGenome {
+	out := make(chan readGenome)
+	pending := make(chan chan readGenome, workers-1)
+	go func() {
+		defer close(pending)
+		for _, file := range files {
+			c := make(chan readGenome, 1)
+			pending <- c
+			go func(file string) {
+				var r readGenome
+				r.a, r.err = readAnnotated(file)
+				if r.err == nil && r.a == nil {
+					r.km, r.err = kmers.Load(file)
+				}
+				c <- r
+			}(file)
+		}
+	}()
+	go func() {
+		defer close(out)
+		for c := range pending {
+			out <- <-c
+		}
+	}()
+	return out
+}
+
+// readAnnotated reads a GFF3 or GenBank file by its extension, or returns nil
+// for anything else.
+func readAnnotated(genome string) (*pangenome.Annotated, error) {
+	var read func(io.Reader) (*pangenome.Annotated, error)
+	switch strings.ToLower(filepath.Ext(genome)) {
+	case ".gff", ".gff3":
+		read = pangenome.ReadGFF3
+	case ".gb", ".gbk", ".genbank":
+		read = pangenome.ReadGenBank
+	default:
+		return nil, nil
+	}
+	f, err := os.Open(genome)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+// sampleName returns the file name of a genome without its extension.
+func sampleName(genome string) string {
+	base := filepath.Base(genome)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	restoreEmbedded bool
+	restoreVerify   bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup <store> <file>",
+	Short: "Archive a pangenome store",
+	Long: `Opens the Badger store read-only and writes a gzipped tar
+					holding the graph's nodes and weighted edges, every Badger
+					key (samples, contig paths, annotations, sketches and
+					unitigs) and a manifest recording K, the sketch settings,
+					the backend and the SHA-256 of each entry.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := configOptions()
+		opts.Dir = args[0]
+		opts.ReadOnly = true
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		m, err := g.Backup(f)
+		if err == nil {
+			err = f.Close()
+		}
+		if err != nil {
+			f.Close()
+			os.Remove(args[1])
+			return err
+		}
+		fmt.Printf("Backed up %v samples, %v nodes, %v edges and %v keys to %s.\n",
+			len(m.Samples), m.Nodes, m.Edges, m.Keys, args[1])
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <file> <store>",
+	Short: "Restore a pangenome store from a backup",
+	Long: `Checks every entry of a backup against its checksum, then
+					loads it into an empty Badger store. With --embedded the
+					graph stays in Badger; otherwise nodes and edges are
+					recreated in Dgraph and uids rewritten to those assigned.
+					With --verify the archive is only checked.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if restoreVerify {
+			m, err := pangenome.VerifyBackup(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s is a valid %s backup from prairiedog v%s: %v samples, %v nodes, %v edges, k=%v.\n",
+				args[0], m.Backend, m.Prairiedog, len(m.Samples), m.Nodes, m.Edges, m.Config.K)
+			return nil
+		}
+
+		opts := configOptions()
+		opts.Dir = args[1]
+		if cmd.Flags().Changed("embedded") {
+			opts.Embedded = restoreEmbedded
+		}
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		ctx, cancel := interruptContext()
+		defer cancel()
+		m, err := g.Restore(args[0], ctx)
+		if interrupted(ctx, err, "The store is partly restored; empty it before trying again.") {
+			g.Close()
+			os.Exit(130)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Restored %v samples, %v nodes and %v edges with k=%v.\n",
+			len(m.Samples), m.Nodes, m.Edges, m.Config.K)
+		return nil
+	},
+}
+
+func init() {
+	restoreCmd.Flags().BoolVar(&restoreEmbedded, "embedded", false, "restore into Badger only, without Dgraph")
+	restoreCmd.Flags().BoolVar(&restoreVerify, "verify", false, "only check the archive's checksums")
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
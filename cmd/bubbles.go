@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/variants"
+)
+
+var bubbleOptions = variants.DefaultOptions
+
+var bubblesCmd = &cobra.Command{
+	Use:   "bubbles",
+	Short: "Find variant bubbles between genomes",
+	Long: `Finds SNP, indel and complex superbubbles in the pangenome. For
+					every allele of a bubble, prints its sequence, the samples
+					traversing it and its frequency from the edge weights, and
+					the annotated genes overlapping the bubble.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Source\tSink\tKind\tAllele\tSequence\tWeight\tFrequency\tSamples\tGenes")
+		for _, b := range variants.FindBubbles(idx, bubbleOptions) {
+			genes := strings.Join(idx.Labels(b.Nodes()), ",")
+			if genes == "" {
+				genes = "-"
+			}
+			for i, a := range b.Alleles {
+				seq := a.Sequence
+				if seq == "" {
+					seq = "-"
+				}
+				fmt.Fprintf(w, "%v\t%v\t%s\t%v\t%s\t%v\t%.4f\t%s\t%s\n", b.Source, b.Sink, b.Kind, i, seq, a.Weight, a.Frequency, strings.Join(a.Samples(), ","), genes)
+			}
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	bubblesCmd.Flags().IntVar(&bubbleOptions.MaxNodes, "max-nodes", variants.DefaultOptions.MaxNodes, "largest number of nodes inside a bubble")
+	bubblesCmd.Flags().IntVar(&bubbleOptions.MaxAlleles, "max-alleles", variants.DefaultOptions.MaxAlleles, "largest number of alleles enumerated per bubble")
+	rootCmd.AddCommand(bubblesCmd)
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	buildResume bool
+	buildList   string
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build [genome]...",
+	Short: "Build the pangenome from many genomes, resumably",
+	Long: `Adds genomes like add, given as arguments or one path a line
+					in --list, while logging each sample's progress in the
+					store, contig by contig. If a build is interrupted or
+					crashes, rerunning it with --resume skips the samples
+					already built and rolls back or finishes the one left
+					half-written, giving the graph an uninterrupted build
+					would have. Until that sample is finished, no other genome
+					can be added. Up to --workers genomes are read at once.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files := args
+		if buildList != "" {
+			listed, err := readList(buildList)
+			if err != nil {
+				return err
+			}
+			files = append(files, listed...)
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no genomes given")
+		}
+		// Sources are recorded as absolute paths, so a build can be resumed
+		// from another directory.
+		for i, file := range files {
+			abs, err := filepath.Abs(file)
+			if err != nil {
+				return err
+			}
+			files[i] = abs
+		}
+
+		g := openGraph()
+		defer g.Close()
+		contextMain, cancel := interruptContext()
+		defer cancel()
+
+		// Don't read genomes again that are already built.
+		var todo []string
+		for _, file := range files {
+			built := false
+			if buildResume {
+				var err error
+				if built, err = g.Built(sampleName(file), file); err != nil {
+					return err
+				}
+			}
+			if built {
+				fmt.Println("Skipped sample:", sampleName(file))
+				continue
+			}
+			todo = append(todo, file)
+		}
+
+		genomes := readGenomes(todo, viper.GetInt("workers"))
+		for _, file := range todo {
+			name := sampleName(file)
+			r := <-genomes
+			if r.err != nil {
+				return r.err
+			}
+			a := r.a
+			if a == nil {
+				a = &pangenome.Annotated{Headers: r.km.Headers, Sequences: r.km.Sequences}
+			}
+			result, err := g.Build(name, file, a, buildResume, contextMain)
+			if interrupted(contextMain, err, "Samples built are kept; rerun with --resume to finish.") {
+				g.Close()
+				os.Exit(130)
+			}
+			if err != nil {
+				return err
+			}
+			switch result {
+			case pangenome.BuildResumed:
+				fmt.Println("Resumed sample:", name)
+			case pangenome.BuildSkipped:
+				fmt.Println("Skipped sample:", name)
+			default:
+				fmt.Println("Added sample:", name)
+			}
+		}
+		return nil
+	},
+}
+
+// readList reads one path a line, ignoring blank lines and those starting
+// with #.
+func readList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	return paths, scanner.Err()
+}
+
+func init() {
+	buildCmd.Flags().BoolVar(&buildResume, "resume", false, "skip samples already built and finish any interrupted")
+	buildCmd.Flags().StringVar(&buildList, "list", "", "file listing genomes, one a line")
+	rootCmd.AddCommand(buildCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/variants"
+)
+
+var (
+	callRef     string
+	callOut     string
+	callOptions = variants.DefaultVCFOptions
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call",
+	Short: "Call variants against a reference genome as VCF",
+	Long: `Detects variant bubbles and projects those traversed by the
+					reference sample onto its contig coordinates. Writes a VCF
+					4.3 file with one haploid genotype column per sample.
+					Multi-allelic sites are kept on one line and insertions
+					longer than --max-insertion are written as <INS>.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if callRef == "" {
+			return fmt.Errorf("--ref is required")
+		}
+
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+		bubbles := variants.FindBubbles(idx, bubbleOptions)
+
+		out := os.Stdout
+		if callOut != "" {
+			out, err = os.Create(callOut)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		if err := variants.WriteVCF(out, idx, bubbles, callRef, callOptions); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	callCmd.Flags().StringVarP(&callRef, "ref", "r", "", "sample to use as the reference")
+	callCmd.Flags().StringVarP(&callOut, "out", "o", "", "VCF file to write (default stdout)")
+	callCmd.Flags().IntVar(&callOptions.MaxInsertion, "max-insertion", variants.DefaultVCFOptions.MaxInsertion, "longest insertion written out in full")
+	callCmd.Flags().IntVar(&bubbleOptions.MaxNodes, "max-nodes", variants.DefaultOptions.MaxNodes, "largest number of nodes inside a bubble")
+	callCmd.Flags().IntVar(&bubbleOptions.MaxAlleles, "max-alleles", variants.DefaultOptions.MaxAlleles, "largest number of alleles enumerated per bubble")
+	rootCmd.AddCommand(callCmd)
+}
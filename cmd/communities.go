@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/analytics"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	communityOptions = analytics.DefaultOptions
+	communityNodes   bool
+)
+
+var communitiesCmd = &cobra.Command{
+	Use:   "communities",
+	Short: "Detect overlapping communities by seed set expansion",
+	Long: `Finds overlapping communities of unitigs (or k-mer nodes, with
+					--nodes) by filtering, seeding, seed set expansion with
+					personalized PageRank and propagation. Each community is
+					printed with the samples found in its members.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := communityOptions.Validate(); err != nil {
+			return err
+		}
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+		var unitigs []*pangenome.Unitig
+		if communityNodes {
+			unitigs = idx.NodeUnitigs()
+		} else {
+			unitigs, err = g.CompactedUnitigs(idx)
+			if err != nil {
+				return err
+			}
+		}
+
+		communities, err := analytics.NewNetwork(idx, unitigs).Communities(communityOptions)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Seed\tMembers\tConductance\tSamples")
+		for _, c := range communities {
+			fmt.Fprintf(w, "%v\t%v\t%.4f\t%s\n", c.Seed, len(c.Members), c.Conductance, formatSamples(idx.Samples, c.Samples))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// formatSamples lists sample:count pairs, most common first.
+func formatSamples(names []string, counts map[int]int) string {
+	indices := make([]int, 0, len(counts))
+	for i := range counts {
+		indices = append(indices, i)
+	}
+	sort.Slice(indices, func(a, b int) bool {
+		if counts[indices[a]] != counts[indices[b]] {
+			return counts[indices[a]] > counts[indices[b]]
+		}
+		return indices[a] < indices[b]
+	})
+	parts := make([]string, len(indices))
+	for j, i := range indices {
+		parts[j] = fmt.Sprintf("%s:%v", names[i], counts[i])
+	}
+	return strings.Join(parts, ",")
+}
+
+func init() {
+	communitiesCmd.Flags().IntVar(&communityOptions.Seeds, "seeds", analytics.DefaultOptions.Seeds, "number of seeds to expand")
+	communitiesCmd.Flags().Float64Var(&communityOptions.Alpha, "alpha", analytics.DefaultOptions.Alpha, "probability of following an edge in PageRank")
+	communitiesCmd.Flags().Float64Var(&communityOptions.Epsilon, "epsilon", analytics.DefaultOptions.Epsilon, "PageRank accuracy")
+	communitiesCmd.Flags().BoolVar(&communityNodes, "nodes", false, "use k-mer nodes instead of unitigs")
+	rootCmd.AddCommand(communitiesCmd)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Collapse non-branching paths into unitigs",
+	Long: `Compacts the k-mer graph by collapsing every maximal non-branching
+					path into a unitig. Each unitig keeps its member k-mers,
+					colours and edge weights. Once compacted, unitigs are kept
+					up to date as genomes are added.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		n, err := g.Compact()
+		if err != nil {
+			return err
+		}
+		fmt.Println("Unitigs:", n)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(compactCmd)
+}
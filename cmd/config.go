@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// envPrefix starts the environment variable for each config key, with dots
+// and dashes in the key turned into underscores: PRAIRIEDOG_BADGER_DIR.
+const envPrefix = "PRAIRIEDOG"
+
+// configKey is a setting read from .prairiedog.yaml, the environment or a
+// persistent flag of that name.
+type configKey struct {
+	key  string
+	flag string
+}
+
+// configKeys are every setting, in the order config show lists them.
+var configKeys = []configKey{
+	{"backend", "backend"},
+	{"dgraph.addresses", "dgraph"},
+	{"dgraph.tls.enabled", "tls"},
+	{"dgraph.tls.ca", "tls-ca"},
+	{"dgraph.tls.cert", "tls-cert"},
+	{"dgraph.tls.key", "tls-key"},
+	{"dgraph.tls.server-name", "tls-server-name"},
+	{"badger.dir", "badger-dir"},
+	{"badger.sync-writes", "sync-writes"},
+	{"badger.truncate", "truncate"},
+	{"badger.value-log-file-size", "value-log-file-size"},
+	{"k", "kmer-size"},
+	{"sketch.k", "sketch-k"},
+	{"sketch.size", "sketch-size"},
+	{"sketch.seed", "sketch-seed"},
+	{"batch-size", "batch-size"},
+	{"workers", "workers"},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect prairiedog's configuration",
+	Long: `Settings are read from $HOME/.prairiedog.yaml (or --config),
+					then PRAIRIEDOG_* environment variables, then flags, each
+					overriding the last. Nested keys are written with dots and
+					dashes, e.g. badger.sync-writes, and in the environment
+					with underscores: PRAIRIEDOG_BADGER_SYNC_WRITES.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the settings in effect and where each comes from",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if f := viper.ConfigFileUsed(); f != "" {
+			fmt.Println("Config file:", f)
+		} else {
+			fmt.Println("Config file: none")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		for _, c := range configKeys {
+			v := viper.Get(c.key)
+			if c.key == "dgraph.addresses" {
+				v = strings.Join(dgraphAddresses(), ",")
+			}
+			fmt.Fprintf(w, "%s:\t%v\t(%s)\n", c.key, v, configSource(c))
+		}
+		w.Flush()
+		if _, err := graphOptions(); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// configSource names where the value of a setting was taken from.
+func configSource(c configKey) string {
+	if rootCmd.PersistentFlags().Changed(c.flag) {
+		return "flag --" + c.flag
+	}
+	env := envPrefix + "_" + envReplacer.Replace(strings.ToUpper(c.key))
+	if _, ok := os.LookupEnv(env); ok {
+		return "env " + env
+	}
+	if viper.InConfig(c.key) {
+		return "config file"
+	}
+	return "default"
+}
+
+var envReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// dgraphAddresses splits the Dgraph addresses on commas as well, since an
+// environment variable holds them as one string.
+func dgraphAddresses() []string {
+	var addrs []string
+	for _, a := range viper.GetStringSlice("dgraph.addresses") {
+		for _, addr := range strings.Split(a, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// graphOptions builds the options for opening the pangenome from the
+// configuration.
+func graphOptions() (pangenome.Options, error) {
+	opts := pangenome.DefaultOptions
+	switch backend := viper.GetString("backend"); backend {
+	case "dgraph":
+	case "embedded":
+		opts.Embedded = true
+	default:
+		return opts, fmt.Errorf("unknown backend %q, expected dgraph or embedded", backend)
+	}
+	opts.DgraphAddrs = dgraphAddresses()
+	opts.TLS = pangenome.TLSOptions{
+		Enabled:    viper.GetBool("dgraph.tls.enabled"),
+		CACert:     viper.GetString("dgraph.tls.ca"),
+		Cert:       viper.GetString("dgraph.tls.cert"),
+		Key:        viper.GetString("dgraph.tls.key"),
+		ServerName: viper.GetString("dgraph.tls.server-name"),
+	}
+	opts.Dir = viper.GetString("badger.dir")
+	opts.SyncWrites = viper.GetBool("badger.sync-writes")
+	opts.Truncate = viper.GetBool("badger.truncate")
+	opts.ValueLogFileSize = viper.GetInt64("badger.value-log-file-size")
+	opts.K = viper.GetInt("k")
+	opts.SketchK = viper.GetInt("sketch.k")
+	opts.SketchSize = viper.GetInt("sketch.size")
+	opts.SketchSeed = viper.GetUint32("sketch.seed")
+	opts.BatchSize = viper.GetInt("batch-size")
+	if opts.K < 0 {
+		return opts, fmt.Errorf("k must not be negative, got %v", opts.K)
+	}
+	if opts.SketchK < 1 {
+		return opts, fmt.Errorf("sketch.k must be at least 1, got %v", opts.SketchK)
+	}
+	if opts.BatchSize < 0 {
+		return opts, fmt.Errorf("batch-size must not be negative, got %v", opts.BatchSize)
+	}
+	if workers := viper.GetInt("workers"); workers < 1 {
+		return opts, fmt.Errorf("workers must be at least 1, got %v", workers)
+	}
+	return opts, nil
+}
+
+// configOptions returns graphOptions, exiting if the configuration is
+// invalid.
+func configOptions() pangenome.Options {
+	opts, err := graphOptions()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return opts
+}
+
+// openGraph opens the pangenome as configured, exiting if it can't.
+func openGraph() *pangenome.Graph {
+	g, err := pangenome.Open(configOptions())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	return g
+}
+
+func init() {
+	d := pangenome.DefaultOptions
+	viper.SetDefault("backend", "dgraph")
+	viper.SetDefault("dgraph.addresses", []string{d.DgraphHost + ":" + d.DgraphPort})
+	viper.SetDefault("dgraph.tls.enabled", false)
+	viper.SetDefault("dgraph.tls.ca", "")
+	viper.SetDefault("dgraph.tls.cert", "")
+	viper.SetDefault("dgraph.tls.key", "")
+	viper.SetDefault("dgraph.tls.server-name", "")
+	viper.SetDefault("badger.dir", d.Dir)
+	viper.SetDefault("badger.sync-writes", d.SyncWrites)
+	viper.SetDefault("badger.truncate", d.Truncate)
+	viper.SetDefault("badger.value-log-file-size", d.ValueLogFileSize)
+	viper.SetDefault("k", d.K)
+	viper.SetDefault("sketch.k", d.SketchK)
+	viper.SetDefault("sketch.size", d.SketchSize)
+	viper.SetDefault("sketch.seed", d.SketchSeed)
+	viper.SetDefault("batch-size", d.BatchSize)
+	viper.SetDefault("workers", runtime.NumCPU())
+
+	flags := rootCmd.PersistentFlags()
+	flags.String("backend", "dgraph", "dgraph, or embedded to keep the graph in Badger only")
+	flags.StringSlice("dgraph", viper.GetStringSlice("dgraph.addresses"), "host:port of each Dgraph server")
+	flags.String("badger-dir", d.Dir, "Badger directory")
+	flags.Bool("tls", d.TLS.Enabled, "connect to Dgraph over TLS")
+	flags.String("tls-ca", d.TLS.CACert, "CA certificate to verify Dgraph with")
+	flags.String("tls-cert", d.TLS.Cert, "client certificate for Dgraph")
+	flags.String("tls-key", d.TLS.Key, "key of the client certificate")
+	flags.String("tls-server-name", d.TLS.ServerName, "name Dgraph's certificate is checked against")
+	flags.Bool("sync-writes", d.SyncWrites, "sync Badger writes to disk before they return")
+	flags.Bool("truncate", d.Truncate, "truncate a corrupt Badger value log instead of failing")
+	flags.Int64("value-log-file-size", d.ValueLogFileSize, "size of each Badger value log file, or 0 for Badger's default")
+	flags.Int("kmer-size", d.K, fmt.Sprintf("length of k-mers; 0 uses the store's, or %v for a new store", pangenome.DefaultK))
+	flags.Int("sketch-k", d.SketchK, "k-mer size of each MinHash sketch")
+	flags.Int("sketch-size", d.SketchSize, "number of hashes in each MinHash sketch")
+	flags.Uint32("sketch-seed", d.SketchSeed, "MinHash seed")
+	flags.Int("batch-size", d.BatchSize, "most writes in one Badger transaction, or 0 for no limit")
+	flags.Int("workers", runtime.NumCPU(), "genomes read at once")
+	for _, c := range configKeys {
+		viper.BindPFlag(c.key, flags.Lookup(c.flag))
+	}
+
+	configCmd.AddCommand(configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
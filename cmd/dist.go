@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+	"github.com/superphy/prairiedog/phylo"
+)
+
+var (
+	distFormat string
+	distOut    string
+)
+
+var distCmd = &cobra.Command{
+	Use:   "dist",
+	Short: "Compute pairwise Mash distances between samples",
+	Long: `Estimates the Mash distance between every pair of samples from
+					the MinHash sketches stored when they were added, and
+					writes the matrix in PHYLIP (phylip) or TSV (tsv) format.
+					Samples without a sketch are skipped.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		d, err := sketchDistances(g)
+		if err != nil {
+			return err
+		}
+
+		var write func(io.Writer) error
+		switch distFormat {
+		case "phylip":
+			write = d.WritePHYLIP
+		case "tsv":
+			write = d.WriteTSV
+		default:
+			return fmt.Errorf("unknown format %q, expected phylip or tsv", distFormat)
+		}
+		if distOut == "" {
+			err = write(os.Stdout)
+		} else {
+			err = writeFile(distOut, write)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// sketchDistances returns the Mash distances between every sample with a
+// stored sketch.
+func sketchDistances(g *pangenome.Graph) (*phylo.Distances, error) {
+	samples, err := g.Samples()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var sketches []*kmers.Sketch
+	for _, sample := range samples {
+		s, err := g.Sketch(sample)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil {
+			log.Printf("WARNING: sample %s has no sketch. Skipping sample.", sample)
+			continue
+		}
+		// Hashes of different k-mer sizes or seeds can't be compared.
+		if len(sketches) > 0 && (s.K != sketches[0].K || s.Seed != sketches[0].Seed) {
+			return nil, fmt.Errorf("samples %s and %s were sketched with different k or seed; add them again with the same sketch settings", names[0], sample)
+		}
+		names = append(names, sample)
+		sketches = append(sketches, s)
+	}
+
+	d := phylo.NewDistances(names)
+	for i := range sketches {
+		for j := i + 1; j < len(sketches); j++ {
+			d.Set(i, j, sketches[i].Distance(sketches[j]))
+		}
+	}
+	return d, nil
+}
+
+func init() {
+	distCmd.Flags().StringVarP(&distFormat, "format", "f", "phylip", "matrix format: phylip or tsv")
+	distCmd.Flags().StringVarP(&distOut, "out", "o", "", "output file (default stdout)")
+	rootCmd.AddCommand(distCmd)
+}
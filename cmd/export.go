@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/matrix"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	matrixFormat  string
+	matrixOut     string
+	matrixNodes   bool
+	matrixMinFreq float64
+	matrixMaxFreq float64
+	matrixDedup   bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the pangenome for other tools",
+}
+
+var exportMatrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Export a samples by features presence/absence matrix",
+	Long: `Writes a binary matrix with one row per sample and one column per
+					unitig (or k-mer node, with --nodes), built from the sample
+					colours. Formats are dense TSV (tsv), sparse Matrix Market
+					(mtx), dense NumPy (npy) and scipy sparse CSR (npz).
+					Alongside the matrix, <out>.rows.txt lists the samples and
+					<out>.features.tsv lists each feature's sequence. With
+					--dedup, features with identical presence patterns are
+					collapsed and <out>.groups.tsv lists the members of each.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := exportMatrix(); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func exportMatrix() error {
+	var write func(*matrix.Matrix, io.Writer) error
+	switch matrixFormat {
+	case "tsv":
+		write = (*matrix.Matrix).WriteTSV
+	case "mtx":
+		write = (*matrix.Matrix).WriteMatrixMarket
+	case "npy":
+		write = (*matrix.Matrix).WriteNpy
+	case "npz":
+		write = (*matrix.Matrix).WriteNpz
+	default:
+		return fmt.Errorf("unknown format %q, expected tsv, mtx, npy or npz", matrixFormat)
+	}
+
+	g := openGraph()
+	defer g.Close()
+
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return err
+	}
+	var unitigs []*pangenome.Unitig
+	if matrixNodes {
+		unitigs = idx.NodeUnitigs()
+	} else {
+		unitigs, err = g.CompactedUnitigs(idx)
+		if err != nil {
+			return err
+		}
+	}
+
+	m := matrix.New(idx.Samples)
+	sequences := make(map[string]string, len(unitigs))
+	for _, u := range unitigs {
+		name := fmt.Sprint(u.ID)
+		m.Add(name, u.Colours.Indices())
+		sequences[name] = u.Sequence
+	}
+	m = m.Filter(matrixMinFreq, matrixMaxFreq)
+
+	if matrixDedup {
+		var groups map[string][]string
+		m, groups = m.Dedup()
+		err := writeFile(matrixOut+".groups.tsv", func(w io.Writer) error {
+			for _, rep := range m.Cols {
+				if _, err := fmt.Fprintf(w, "%s\t%s\n", rep, strings.Join(groups[rep], ",")); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeFile(fmt.Sprintf("%s.%s", matrixOut, matrixFormat), func(w io.Writer) error {
+		return write(m, w)
+	})
+	if err != nil {
+		return err
+	}
+	err = writeFile(matrixOut+".rows.txt", m.WriteRows)
+	if err != nil {
+		return err
+	}
+	return writeFile(matrixOut+".features.tsv", func(w io.Writer) error {
+		for j, name := range m.Cols {
+			if _, err := fmt.Fprintf(w, "%s\t%v\t%s\n", name, len(m.Columns[j]), sequences[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeFile creates path and writes to it with fn.
+func writeFile(path string, fn func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func init() {
+	exportMatrixCmd.Flags().StringVarP(&matrixFormat, "format", "f", "tsv", "matrix format: tsv, mtx, npy or npz")
+	exportMatrixCmd.Flags().StringVarP(&matrixOut, "out", "o", "pangenome", "prefix for output files")
+	exportMatrixCmd.Flags().BoolVar(&matrixNodes, "nodes", false, "use k-mer nodes as features instead of unitigs")
+	exportMatrixCmd.Flags().Float64Var(&matrixMinFreq, "min-freq", 0, "minimum fraction of samples containing a feature")
+	exportMatrixCmd.Flags().Float64Var(&matrixMaxFreq, "max-freq", 1, "maximum fraction of samples containing a feature")
+	exportMatrixCmd.Flags().BoolVar(&matrixDedup, "dedup", false, "collapse features with identical presence patterns")
+	exportCmd.AddCommand(exportMatrixCmd)
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	familyOptions = pangenome.DefaultFamilyOptions
+	familiesOut   string
+)
+
+var familiesCmd = &cobra.Command{
+	Use:   "families",
+	Short: "Cluster annotated genes into families",
+	Long: `Clusters the annotated genes of every sample by the k-mers
+					they share, on either strand, and writes the families as Roary's
+					gene_presence_absence.csv and gene_presence_absence.Rtab
+					in the output directory. Only samples with genes of the
+					chosen type are included.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+
+		families := idx.Families(familyOptions)
+		annotated := make(map[string]bool)
+		for _, f := range families {
+			for _, s := range f.Samples() {
+				annotated[s] = true
+			}
+		}
+		var samples []string
+		for _, s := range idx.Samples {
+			if annotated[s] {
+				samples = append(samples, s)
+			}
+		}
+
+		if err := os.MkdirAll(familiesOut, 0755); err != nil {
+			return err
+		}
+		err = writeFile(filepath.Join(familiesOut, "gene_presence_absence.csv"), func(w io.Writer) error {
+			return pangenome.WriteGenePresenceAbsence(w, families, samples)
+		})
+		if err == nil {
+			err = writeFile(filepath.Join(familiesOut, "gene_presence_absence.Rtab"), func(w io.Writer) error {
+				return pangenome.WriteRtab(w, families, samples)
+			})
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %v gene families across %v samples.\n", len(families), len(samples))
+		return nil
+	},
+}
+
+func init() {
+	familiesCmd.Flags().StringVar(&familyOptions.Type, "type", pangenome.DefaultFamilyOptions.Type, "feature type to cluster")
+	familiesCmd.Flags().Float64Var(&familyOptions.MinJaccard, "min-jaccard", pangenome.DefaultFamilyOptions.MinJaccard, "smallest share of k-mers between genes in a family")
+	familiesCmd.Flags().StringVarP(&familiesOut, "out", "o", ".", "output directory")
+	rootCmd.AddCommand(familiesCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var geneCmd = &cobra.Command{
+	Use:   "gene <name>",
+	Short: "List the nodes of an annotated gene",
+	Long: `Prints every annotated feature with the given name or id, in
+					any sample, followed by the k-mer nodes they cover.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+
+		nodes := idx.FeatureNodes(args[0])
+		if len(nodes) == 0 {
+			fmt.Printf("No features named %s.\n", args[0])
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Sample\tContig\tType\tStart\tEnd\tStrand\tNodes")
+		for _, f := range idx.FeaturesAt(nodes) {
+			if f.Name != args[0] && f.ID != args[0] {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%v\t%s\t%v\n", f.Sample, f.Contig, f.Type, f.Start, f.End, f.Strand, len(f.Nodes))
+		}
+		w.Flush()
+		fmt.Println()
+		fmt.Println(formatIDs(nodes))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(geneCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	genotypeThresholds = pangenome.DefaultThresholds
+	genotypeNearest    int
+)
+
+var genotypeCmd = &cobra.Command{
+	Use:   "genotype <store> <genome.fasta>",
+	Short: "Genotype a genome against the pangenome without adding it",
+	Long: `Opens the Badger store read-only and compares a genome's
+					k-mers and edges to the graph. Reports novel k-mers and
+					edges, which accessory components (connected unitigs
+					outside the core) are present or absent, and the nearest
+					samples by the Jaccard index of shared k-mers.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := genotypeThresholds.Validate(); err != nil {
+			return err
+		}
+
+		opts := configOptions()
+		opts.Dir = args[0]
+		opts.ReadOnly = true
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+		unitigs, err := g.CompactedUnitigs(idx)
+		if err != nil {
+			return err
+		}
+
+		km, err := kmers.Load(args[1])
+		if err != nil {
+			return err
+		}
+		gt := idx.Genotype(sampleName(args[1]), km.Sequences, unitigs, genotypeThresholds)
+
+		fmt.Printf("Sample\t%s\n", gt.Sample)
+		fmt.Printf("Novel k-mers\t%v of %v\n", gt.Novel, gt.Kmers)
+		fmt.Printf("Novel edges\t%v of %v\n", gt.NovelEdges, gt.Edges)
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Component\tUnitigs\tK-mers found\tStatus")
+		for _, c := range gt.Components {
+			status := "absent"
+			if c.Present {
+				status = "present"
+			}
+			fmt.Fprintf(w, "%v\t%s\t%v/%v\t%s\n", c.Unitigs[0], formatIDs(c.Unitigs), c.Found, c.Kmers, status)
+		}
+		w.Flush()
+		fmt.Println()
+
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Nearest\tShared k-mers\tJaccard")
+		for i, s := range gt.Nearest {
+			if i == genotypeNearest {
+				break
+			}
+			fmt.Fprintf(w, "%s\t%v\t%.4f\n", s.Sample, s.Shared, s.Jaccard)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// formatIDs joins ids with commas.
+func formatIDs(ids []uint64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprint(id)
+	}
+	return strings.Join(parts, ",")
+}
+
+func init() {
+	genotypeCmd.Flags().Float64Var(&genotypeThresholds.Core, "core", pangenome.DefaultThresholds.Core, "minimum fraction of samples for core")
+	genotypeCmd.Flags().Float64Var(&genotypeThresholds.SoftCore, "soft-core", pangenome.DefaultThresholds.SoftCore, "minimum fraction of samples for soft-core")
+	genotypeCmd.Flags().Float64Var(&genotypeThresholds.Shell, "shell", pangenome.DefaultThresholds.Shell, "minimum fraction of samples for shell")
+	genotypeCmd.Flags().IntVarP(&genotypeNearest, "nearest", "n", 5, "number of nearest samples to report")
+	rootCmd.AddCommand(genotypeCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+	"github.com/superphy/prairiedog/rpc"
+	"google.golang.org/grpc"
+)
+
+// grpcShutdownTimeout is how long calls in flight have to finish once the
+// server is asked to stop.
+const grpcShutdownTimeout = 30 * time.Second
+
+var (
+	grpcAddr     string
+	grpcOptions  rpc.Options
+	grpcStore    string
+	grpcEmbedded bool
+)
+
+var grpcCmd = &cobra.Command{
+	Use:   "grpc",
+	Short: "Serve the pangenome over gRPC",
+	Long: `Starts a gRPC server for the service in rpc/prairiedog.proto:
+					genomes are streamed in as chunks of contigs, one sample
+					after another, with a report for each sample, and
+					traversals and paths are streamed back. Messages are
+					protobuf, so stubs generated from the proto work, or JSON
+					for clients setting the json content-subtype. With
+					--read-only, the store is opened read-only and genomes
+					can't be added.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := configOptions()
+		if grpcStore != "" {
+			opts.Dir = grpcStore
+		}
+		opts.ReadOnly = grpcOptions.ReadOnly
+		if cmd.Flags().Changed("embedded") {
+			opts.Embedded = grpcEmbedded
+		}
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		lis, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			return err
+		}
+		s := grpc.NewServer()
+		rpc.RegisterPangenomeServer(s, rpc.New(g, grpcOptions))
+		// On SIGINT or SIGTERM, let calls in flight finish before the store
+		// is closed, cancelling any still running after grpcShutdownTimeout.
+		// A cancelled AddGenome leaves its sample unregistered.
+		ctx, cancel := interruptContext()
+		defer cancel()
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			<-ctx.Done()
+			t := time.AfterFunc(grpcShutdownTimeout, s.Stop)
+			defer t.Stop()
+			s.GracefulStop()
+		}()
+		log.Printf("Serving gRPC on %s.", grpcAddr)
+		if err := s.Serve(lis); err != nil {
+			return err
+		}
+		<-stopped
+		log.Println("Stopped.")
+		return nil
+	},
+}
+
+func init() {
+	grpcCmd.Flags().StringVar(&grpcAddr, "addr", ":9090", "address to listen on")
+	grpcCmd.Flags().StringVar(&grpcStore, "store", "", "Badger directory, instead of badger.dir")
+	grpcCmd.Flags().BoolVar(&grpcEmbedded, "embedded", false, "keep the graph in Badger only, without Dgraph")
+	grpcCmd.Flags().BoolVar(&grpcOptions.ReadOnly, "read-only", false, "open the store read-only and reject writes")
+	rootCmd.AddCommand(grpcCmd)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import pangenomes built by other tools",
+	Long: `Import reads a pangenome graph written by another tool and
+					loads it into prairiedog.`,
+}
+
+var importGFACmd = &cobra.Command{
+	Use:   "gfa <file.gfa>",
+	Short: "Import the paths of a GFA file as samples",
+	Long: `Reads the segments, links and paths of a GFA 1.x file (e.g. from
+					minigraph or pggb), spells out every path and decomposes it
+					into k-mer nodes. Paths are grouped into samples by their
+					PanSN prefix (sample#haplotype#contig). K-mers already in the
+					graph are reused, so a GFA can seed a new pangenome or be
+					merged into an existing one.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		gfa, err := pangenome.ReadGFA(f)
+		if err != nil {
+			return err
+		}
+
+		g := openGraph()
+		defer g.Close()
+		contextMain, cancel := interruptContext()
+		defer cancel()
+
+		samples, err := g.ImportGFA(gfa, contextMain)
+		for _, sample := range samples {
+			fmt.Println("Imported sample:", sample)
+		}
+		if interrupted(contextMain, err, "The samples imported are kept.") {
+			g.Close()
+			os.Exit(130)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	importCmd.AddCommand(importGFACmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	migrateFrom    string
+	migrateTo      string
+	migrateOptions = pangenome.DefaultMigrateOptions
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --from <backend> --to <backend>",
+	Short: "Move a pangenome between Dgraph and embedded backends",
+	Long: `Copies every node, edge, contig path and key from one backend
+					to another, given as dgraph://host:port?badger=<dir> or
+					badger://<dir>. The source is opened read-only. Progress
+					is checkpointed in the target, so rerunning an interrupted
+					migration resumes it. Afterwards counts and the
+					neighbourhoods of randomly picked nodes are compared.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if migrateFrom == "" || migrateTo == "" {
+			return fmt.Errorf("both --from and --to are needed")
+		}
+		from, err := pangenome.ParseBackend(migrateFrom)
+		if err != nil {
+			return err
+		}
+		to, err := pangenome.ParseBackend(migrateTo)
+		if err != nil {
+			return err
+		}
+		from.ReadOnly = true
+		src, err := pangenome.Open(from)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := pangenome.Open(to)
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+
+		ctx, cancel := interruptContext()
+		defer cancel()
+		report, err := pangenome.Migrate(src, dst, migrateFrom, migrateOptions, ctx)
+		if interrupted(ctx, err, "Rerun the migration to resume it.") {
+			src.Close()
+			dst.Close()
+			os.Exit(130)
+		}
+		if err != nil {
+			return err
+		}
+		if report.Resumed {
+			fmt.Println("Resumed an interrupted migration.")
+		}
+		fmt.Printf("Migrated %v samples, %v nodes, %v edges and %v keys.\n",
+			report.Samples, report.Nodes, report.Edges, report.Keys)
+		fmt.Printf("Verified the neighbourhoods of %v nodes.\n", report.Verified)
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&migrateFrom, "from", "", "backend to copy from")
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "backend to copy into")
+	migrateCmd.Flags().IntVar(&migrateOptions.Checkpoint, "checkpoint", pangenome.DefaultMigrateOptions.Checkpoint, "records copied between checkpoints")
+	migrateCmd.Flags().IntVar(&migrateOptions.Verify, "verify", pangenome.DefaultMigrateOptions.Verify, "nodes whose neighbourhoods are compared")
+	migrateCmd.Flags().Int64Var(&migrateOptions.Seed, "seed", pangenome.DefaultMigrateOptions.Seed, "seed for picking nodes to verify")
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var packCmd = &cobra.Command{
+	Use:   "pack <store> <file.pdg>",
+	Short: "Write the pangenome to a single portable file",
+	Long: `Opens the Badger store read-only and writes the graph as a
+					PDG file: k-mers packed 2 bits a base, edges with weights,
+					colours and contig paths in one versioned binary file that
+					is memory-mapped and queried without a database, e.g. with
+					query --pdg. K can be at most 32.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := configOptions()
+		opts.Dir = args[0]
+		opts.ReadOnly = true
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+		if err := writeFile(args[1], func(w io.Writer) error {
+			return pangenome.WritePDG(w, idx)
+		}); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(packCmd)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	partitionThresholds = pangenome.DefaultThresholds
+	partitionNodes      bool
+	partitionOut        string
+)
+
+var partitionCmd = &cobra.Command{
+	Use:   "partition",
+	Short: "Partition the pangenome into core and accessory genomes",
+	Long: `Classifies every unitig (or k-mer node, with --nodes) as core,
+					soft-core, shell or cloud by the fraction of samples
+					containing it. Each partition is written as FASTA and as a
+					GFA subgraph, and a Roary-style summary is printed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := partitionThresholds.Validate(); err != nil {
+			return err
+		}
+
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+		var unitigs []*pangenome.Unitig
+		if partitionNodes {
+			unitigs = idx.NodeUnitigs()
+		} else {
+			unitigs, err = g.CompactedUnitigs(idx)
+			if err != nil {
+				return err
+			}
+		}
+
+		parts := idx.Partition(unitigs, partitionThresholds)
+		for _, p := range pangenome.Partitions {
+			if err := writePartition(partitionOut, p, idx, parts[p]); err != nil {
+				return err
+			}
+		}
+
+		f, err := os.Create(partitionOut + ".summary.txt")
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pangenome.WritePartitionSummary(f, parts, partitionThresholds); err != nil {
+			return err
+		}
+		pangenome.WritePartitionSummary(os.Stdout, parts, partitionThresholds)
+		return nil
+	},
+}
+
+// writePartition writes <prefix>.<partition>.fasta and .gfa.
+func writePartition(prefix string, p pangenome.Partition, idx *pangenome.Index, unitigs []*pangenome.Unitig) error {
+	fasta, err := os.Create(fmt.Sprintf("%s.%s.fasta", prefix, p))
+	if err != nil {
+		return err
+	}
+	defer fasta.Close()
+	if err := pangenome.WriteFasta(fasta, unitigs); err != nil {
+		return err
+	}
+
+	gfa, err := os.Create(fmt.Sprintf("%s.%s.gfa", prefix, p))
+	if err != nil {
+		return err
+	}
+	defer gfa.Close()
+	return pangenome.WriteGFA(gfa, idx, unitigs)
+}
+
+func init() {
+	partitionCmd.Flags().Float64Var(&partitionThresholds.Core, "core", pangenome.DefaultThresholds.Core, "minimum fraction of samples for core")
+	partitionCmd.Flags().Float64Var(&partitionThresholds.SoftCore, "soft-core", pangenome.DefaultThresholds.SoftCore, "minimum fraction of samples for soft-core")
+	partitionCmd.Flags().Float64Var(&partitionThresholds.Shell, "shell", pangenome.DefaultThresholds.Shell, "minimum fraction of samples for shell")
+	partitionCmd.Flags().BoolVar(&partitionNodes, "nodes", false, "classify k-mer nodes instead of unitigs")
+	partitionCmd.Flags().StringVarP(&partitionOut, "out", "o", "pangenome", "prefix for output files")
+	rootCmd.AddCommand(partitionCmd)
+}
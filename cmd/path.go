@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var pathK int
+
+var pathCmd = &cobra.Command{
+	Use:   "path <kmerA> <kmerB>",
+	Short: "Find paths between two k-mers",
+	Long: `Finds the shortest path by number of edges, the most probable
+					path under the edge weights and the k most probable
+					alternatives between two k-mers. Each path is printed with
+					its spelled-out sequence and per-step probabilities.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+
+		shortest, err := idx.ShortestPath(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		if shortest == nil {
+			fmt.Printf("No path from %s to %s.\n", args[0], args[1])
+			return nil
+		}
+		printPath("Shortest", shortest)
+
+		paths, err := idx.KBestPaths(args[0], args[1], pathK)
+		if err != nil {
+			return err
+		}
+		for i, p := range paths {
+			name := fmt.Sprintf("Alternative %v", i)
+			if i == 0 {
+				name = "Most probable"
+			}
+			printPath(name, p)
+		}
+		return nil
+	},
+}
+
+func printPath(name string, p *pangenome.Path) {
+	steps := make([]string, len(p.Probabilities))
+	for i, prob := range p.Probabilities {
+		steps[i] = fmt.Sprintf("%.4g", prob)
+	}
+	fmt.Printf("%s (%v edges, probability %.4g)\n", name, len(p.Nodes)-1, p.Probability)
+	fmt.Println(p.Sequence)
+	fmt.Println(strings.Join(steps, " "))
+}
+
+func init() {
+	pathCmd.Flags().IntVarP(&pathK, "k", "k", 3, "number of most probable paths")
+	rootCmd.AddCommand(pathCmd)
+}
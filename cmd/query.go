@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var queryPDG string
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sequence|file.fasta>",
+	Short: "Map a query sequence onto the pangenome",
+	Long: `Looks up the k-mers of a query sequence, given directly or as
+					the first record of a fasta file, and reports the fraction
+					of the query found in the graph, the stretches that are
+					missing, and for each sample the percentage of the query
+					found and the contig coordinates of matching stretches.
+					Coordinates are 0-based and half-open. With --pdg, a file
+					written by pack is queried instead of the store.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		seq, err := querySequence(args[0])
+		if err != nil {
+			return err
+		}
+
+		var q pangenome.Querier
+		if queryPDG != "" {
+			p, err := pangenome.OpenPDG(queryPDG)
+			if err != nil {
+				return err
+			}
+			q = p
+		} else {
+			q = openGraph()
+		}
+		defer q.Close()
+
+		loc, err := q.Locate(seq)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Found %v of %v k-mers (%.2f%%)\n", loc.Found, loc.Kmers, 100*loc.Coverage)
+		for _, b := range loc.Breaks {
+			fmt.Printf("Missing %v-%v\n", b.Start, b.End)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Sample\tIdentity\tContig\tStrand\tQuery\tContig position")
+		for _, s := range loc.Samples {
+			for _, st := range s.Stretches {
+				fmt.Fprintf(w, "%s\t%.2f\t%s\t%s\t%v-%v\t%v-%v\n", s.Sample, s.Identity, st.Contig, st.Strand, st.Query.Start, st.Query.End, st.Target.Start, st.Target.End)
+			}
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+// querySequence reads the first record of a fasta file, or returns arg as the
+// sequence if no such file exists.
+func querySequence(arg string) (string, error) {
+	f, err := os.Open(arg)
+	if os.IsNotExist(err) {
+		return strings.ToUpper(arg), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var seq strings.Builder
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+	started := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, ">") {
+			if started {
+				break
+			}
+			started = true
+			continue
+		}
+		seq.WriteString(strings.ToUpper(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return seq.String(), nil
+}
+
+func init() {
+	queryCmd.Flags().StringVar(&queryPDG, "pdg", "", "query a PDG file instead of the store")
+	rootCmd.AddCommand(queryCmd)
+}
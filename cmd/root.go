@@ -10,7 +10,7 @@ import (
 	"github.com/superphy/prairiedog/pangenome"
 )
 
-var cfgFile, projectBase, userLicense string
+var cfgFile string
 
 var rootCmd = &cobra.Command{
 	Use:   "prairiedog",
@@ -18,8 +18,12 @@ var rootCmd = &cobra.Command{
 	Long: `A pangenome graph generator with storage in Dgraph
 					and Bagder. Implements a cross between a De Bruijn
 					Graph and a Li-Stephen model. Source: github.com/superphy/prairiedog.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		pangenome.Run()
+	// Commands return their errors for Execute to print, once their
+	// deferred cleanup has run. Usage is only printed for mistakes in the
+	// arguments and flags, found before a command runs.
+	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cmd.SilenceUsage = true
 	},
 }
 
@@ -27,15 +31,6 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.prairiedog.yaml)")
-	rootCmd.PersistentFlags().StringVarP(&projectBase, "projectbase", "b", "", "github.com/superphy/")
-	rootCmd.PersistentFlags().StringP("author", "a", "NML", "National Microbiology Lab")
-	rootCmd.PersistentFlags().StringVarP(&userLicense, "license", "l", "", "Apache 2.0")
-	rootCmd.PersistentFlags().Bool("viper", true, "Use Viper for configuration")
-	viper.BindPFlag("author", rootCmd.PersistentFlags().Lookup("author"))
-	viper.BindPFlag("projectbase", rootCmd.PersistentFlags().Lookup("projectbase"))
-	viper.BindPFlag("useViper", rootCmd.PersistentFlags().Lookup("viper"))
-	viper.SetDefault("author", "NML chad.laing@canada.ca")
-	viper.SetDefault("license", "Apache 2.0")
 
 	rootCmd.AddCommand(versionCmd)
 }
@@ -53,16 +48,23 @@ func initConfig() {
 			os.Exit(1)
 		}
 
-		// Search config in home directory with name ".prairiedog2" (without extension).
+		// Search config in home directory with name ".prairiedog" (without extension).
 		viper.AddConfigPath(home)
 		viper.SetConfigName(".prairiedog")
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Read in environment variables that match, e.g. PRAIRIEDOG_BADGER_DIR.
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envReplacer)
+	viper.AutomaticEnv()
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in. A config file given with
+	// --config has to be readable.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	} else if cfgFile != "" {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 }
 
@@ -71,7 +73,7 @@ var versionCmd = &cobra.Command{
 	Short: "Print the version number of prairiedog",
 	Long:  `All software has versions. This is prairiedogs's`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("prairiedog v0.0.1")
+		fmt.Println("prairiedog v" + pangenome.Version)
 	},
 }
 
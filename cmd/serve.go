@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+	"github.com/superphy/prairiedog/server"
+)
+
+var (
+	serveAddr     string
+	serveOptions  = server.DefaultOptions
+	serveStore    string
+	serveEmbedded bool
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the pangenome over HTTP",
+	Long: `Starts an HTTP server answering JSON queries: node lookup,
+					neighbours with weights, sample lists, sequence location,
+					paths, stats and subgraph export as JSON or GFA. Lists are
+					paginated with offset and limit. With --read-only, the
+					store is opened read-only and genomes can't be added.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := configOptions()
+		if serveStore != "" {
+			opts.Dir = serveStore
+		}
+		opts.ReadOnly = serveOptions.ReadOnly
+		if cmd.Flags().Changed("embedded") {
+			opts.Embedded = serveEmbedded
+		}
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		srv := &http.Server{
+			Addr:         serveAddr,
+			Handler:      server.New(g, serveOptions).Handler(),
+			ReadTimeout:  serveOptions.Timeout,
+			WriteTimeout: serveOptions.Timeout + 5*time.Second,
+		}
+		// On SIGINT or SIGTERM, let requests in flight finish before the
+		// store is closed.
+		ctx, cancel := interruptContext()
+		defer cancel()
+		stopped := make(chan struct{})
+		go func() {
+			defer close(stopped)
+			<-ctx.Done()
+			shutdown, done := context.WithTimeout(context.Background(), serveOptions.Timeout)
+			defer done()
+			srv.Shutdown(shutdown)
+		}()
+		log.Printf("Serving on %s.", serveAddr)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		<-stopped
+		log.Println("Stopped.")
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "address to listen on")
+	serveCmd.Flags().StringVar(&serveStore, "store", "", "Badger directory, instead of badger.dir")
+	serveCmd.Flags().BoolVar(&serveEmbedded, "embedded", false, "keep the graph in Badger only, without Dgraph")
+	serveCmd.Flags().BoolVar(&serveOptions.ReadOnly, "read-only", false, "open the store read-only and reject writes")
+	serveCmd.Flags().DurationVar(&serveOptions.Timeout, "timeout", server.DefaultOptions.Timeout, "longest a request may take")
+	serveCmd.Flags().IntVar(&serveOptions.PageSize, "page-size", server.DefaultOptions.PageSize, "items per page by default")
+	serveCmd.Flags().Int64Var(&serveOptions.MaxBody, "max-body", server.DefaultOptions.MaxBody, "largest genome that may be added, in bytes")
+	rootCmd.AddCommand(serveCmd)
+}
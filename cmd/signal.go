@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context cancelled on SIGINT or SIGTERM, so long
+// commands stop at their next safe point and close the store cleanly. A
+// second signal exits at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-sigs:
+			log.Printf("Received %v, stopping; send it again to exit at once.", sig)
+			cancel()
+		case <-ctx.Done():
+			return
+		}
+		<-sigs
+		os.Exit(130)
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
+
+// interrupted reports whether err came from the command being interrupted,
+// logging what's been kept if so.
+func interrupted(ctx context.Context, err error, kept string) bool {
+	if err == nil || ctx.Err() == nil {
+		return false
+	}
+	log.Printf("Interrupted. %s", kept)
+	return true
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	simulateStart   string
+	simulateEnd     string
+	simulateSeed    int64
+	simulateCount   int
+	simulateOut     string
+	simulateOptions = pangenome.DefaultSimulationOptions
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulate haplotypes from the weighted edges",
+	Long: `Simulates haplotypes by weighted random walks over the pangenome
+					following a Li-Stephens model: each walk copies from one
+					sample at a time, switching samples with the recombination
+					probability. Walks start at --start and, if given, must
+					reach --end. Output is FASTA, and the same --seed always
+					gives the same haplotypes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if simulateStart == "" {
+			return fmt.Errorf("--start is required")
+		}
+
+		g := openGraph()
+		defer g.Close()
+
+		idx, err := g.LoadIndex()
+		if err != nil {
+			return err
+		}
+
+		out := os.Stdout
+		if simulateOut != "" {
+			out, err = os.Create(simulateOut)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+
+		rng := rand.New(rand.NewSource(simulateSeed))
+		for i := 0; i < simulateCount; i++ {
+			seq, err := idx.SampleHaplotype(simulateStart, simulateEnd, rng, simulateOptions)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, ">haplotype_%v seed=%v\n%s\n", i+1, simulateSeed, seq)
+		}
+		return nil
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateStart, "start", "", "k-mer to start each walk from")
+	simulateCmd.Flags().StringVar(&simulateEnd, "end", "", "k-mer each walk must end at (default: walk to a dead end)")
+	simulateCmd.Flags().Int64Var(&simulateSeed, "seed", 1, "random seed")
+	simulateCmd.Flags().IntVarP(&simulateCount, "count", "n", 1, "number of haplotypes")
+	simulateCmd.Flags().StringVarP(&simulateOut, "out", "o", "", "FASTA file to write (default stdout)")
+	simulateCmd.Flags().Float64Var(&simulateOptions.Recombination, "recombination", pangenome.DefaultSimulationOptions.Recombination, "probability of switching samples at each step")
+	simulateCmd.Flags().Float64Var(&simulateOptions.Mutation, "mutation", pangenome.DefaultSimulationOptions.Mutation, "probability of mutating each base")
+	simulateCmd.Flags().IntVar(&simulateOptions.MaxLength, "max-length", pangenome.DefaultSimulationOptions.MaxLength, "longest haplotype, in bases")
+	simulateCmd.Flags().IntVar(&simulateOptions.Attempts, "attempts", pangenome.DefaultSimulationOptions.Attempts, "walks tried per haplotype before giving up")
+	rootCmd.AddCommand(simulateCmd)
+}
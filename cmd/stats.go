@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var statsFormat string
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Print statistics about the pangenome",
+	Long: `Reports node, edge and sample counts, the degree distribution,
+					branching nodes, weakly connected components, unitig N50,
+					stored bytes per backend and per-sample k-mer counts.
+					Dgraph doesn't report its size, so only Badger's bytes
+					are shown.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		s, err := g.Stats()
+		if err != nil {
+			return err
+		}
+
+		switch statsFormat {
+		case "json":
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(s)
+		case "table":
+			err = writeStatsTable(os.Stdout, s)
+		default:
+			err = fmt.Errorf("unknown format %q, expected table or json", statsFormat)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+func writeStatsTable(out io.Writer, s *pangenome.Stats) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Nodes\t%v\n", s.Nodes)
+	fmt.Fprintf(w, "Edges\t%v\n", s.Edges)
+	fmt.Fprintf(w, "Samples\t%v\n", s.Samples)
+	fmt.Fprintf(w, "Branching nodes\t%v\n", s.BranchingNodes)
+	fmt.Fprintf(w, "Components\t%v\n", s.Components)
+	fmt.Fprintf(w, "Unitigs\t%v\n", s.Unitigs)
+	fmt.Fprintf(w, "Unitig N50\t%v\n", s.UnitigN50)
+	for _, backend := range sortedKeys(s.Bytes) {
+		fmt.Fprintf(w, "Bytes (%s)\t%v\n", backend, s.Bytes[backend])
+	}
+
+	fmt.Fprintf(w, "\nDegree\tIn\tOut\n")
+	var degrees []int
+	for d := range s.InDegrees {
+		degrees = append(degrees, d)
+	}
+	for d := range s.OutDegrees {
+		if _, ok := s.InDegrees[d]; !ok {
+			degrees = append(degrees, d)
+		}
+	}
+	sort.Ints(degrees)
+	for _, d := range degrees {
+		fmt.Fprintf(w, "%v\t%v\t%v\n", d, s.InDegrees[d], s.OutDegrees[d])
+	}
+
+	fmt.Fprintf(w, "\nSample\tK-mers\tNovel\tUnique\n")
+	for _, ss := range s.SampleStats {
+		fmt.Fprintf(w, "%s\t%v\t%v\t%v\n", ss.Name, ss.Kmers, ss.Novel, ss.Unique)
+	}
+	return w.Flush()
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	statsCmd.Flags().StringVarP(&statsFormat, "format", "f", "table", "output format: table or json")
+	rootCmd.AddCommand(statsCmd)
+}
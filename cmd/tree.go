@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+	"github.com/superphy/prairiedog/phylo"
+)
+
+var (
+	treeDistance   string
+	treeThresholds = pangenome.DefaultThresholds
+	treeOut        string
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Build a neighbor-joining tree of the samples",
+	Long: `Computes distances between every pair of samples and builds a
+					neighbor-joining tree, written in Newick format. Distances
+					are the Jaccard distance of shared k-mer nodes (jaccard) or
+					edges (edges), the fraction of accessory unitigs present in
+					only one sample (accessory), or the Mash distance of the
+					stored sketches (mash).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		g := openGraph()
+		defer g.Close()
+
+		var d *phylo.Distances
+		var err error
+		if treeDistance == "mash" {
+			d, err = sketchDistances(g)
+		} else {
+			d, err = colourDistances(g, treeDistance)
+		}
+		if err != nil {
+			return err
+		}
+
+		t := phylo.NeighborJoining(d)
+		if treeOut == "" {
+			err = t.WriteNewick(os.Stdout)
+		} else {
+			err = writeFile(treeOut, t.WriteNewick)
+		}
+		if err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// colourDistances returns distances between samples from their colours in the
+// graph.
+func colourDistances(g *pangenome.Graph, distance string) (*phylo.Distances, error) {
+	if distance == "accessory" && (treeThresholds.Core <= 0 || treeThresholds.Core > 1) {
+		return nil, fmt.Errorf("core threshold must be in (0, 1], got %v", treeThresholds.Core)
+	}
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	switch distance {
+	case "jaccard":
+		return phylo.JaccardDistances(idx), nil
+	case "edges":
+		return phylo.EdgeJaccardDistances(idx), nil
+	case "accessory":
+		unitigs, err := g.CompactedUnitigs(idx)
+		if err != nil {
+			return nil, err
+		}
+		return phylo.AccessoryDistances(idx, unitigs, treeThresholds), nil
+	}
+	return nil, fmt.Errorf("unknown distance %q, expected jaccard, edges, accessory or mash", distance)
+}
+
+func init() {
+	treeCmd.Flags().StringVarP(&treeDistance, "distance", "d", "jaccard", "distance: jaccard, edges, accessory or mash")
+	treeCmd.Flags().Float64Var(&treeThresholds.Core, "core", pangenome.DefaultThresholds.Core, "minimum fraction of samples for core, with accessory distances")
+	treeCmd.Flags().StringVarP(&treeOut, "out", "o", "", "output file (default stdout)")
+	rootCmd.AddCommand(treeCmd)
+}
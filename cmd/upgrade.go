@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+var (
+	upgradeEmbedded bool
+	upgradeDryRun   bool
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade <store>",
+	Short: "Upgrade a store to the current schema version",
+	Long: `Reports the schema version of a store and each step needed to
+					bring it up to the version this prairiedog writes, then
+					runs them. Stores are also upgraded whenever they're
+					opened for writing; read-only commands refuse stores
+					needing an upgrade. With --dry-run nothing is changed.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := configOptions()
+		opts.Dir = args[0]
+		if cmd.Flags().Changed("embedded") {
+			opts.Embedded = upgradeEmbedded
+		}
+		opts.ReadOnly = upgradeDryRun
+		opts.NoUpgrade = true
+		g, err := pangenome.Open(opts)
+		if err != nil {
+			return err
+		}
+		defer g.Close()
+
+		v, err := g.StoredSchema()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Store has schema v%v; prairiedog v%s writes v%v.\n", v, pangenome.Version, pangenome.SchemaVersion)
+		if v > pangenome.SchemaVersion {
+			return fmt.Errorf("store is newer than this prairiedog; upgrade prairiedog instead")
+		}
+		steps := pangenome.PendingSteps(v)
+		if len(steps) == 0 {
+			fmt.Println("Nothing to upgrade.")
+			return nil
+		}
+		for _, step := range steps {
+			fmt.Printf("v%v: %s\n", step.Version, step.Description)
+		}
+		if upgradeDryRun {
+			return nil
+		}
+		if _, err := g.Upgrade(); err != nil {
+			return err
+		}
+		fmt.Printf("Upgraded to v%v.\n", pangenome.SchemaVersion)
+		return nil
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeEmbedded, "embedded", false, "open the store without Dgraph")
+	upgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "only report the steps needed")
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -16,13 +16,13 @@ type Kmers struct {
 	li        int      // line index in Headers and Sequences.
 	pi        int      // position index in a slice of Sequences.
 	K         int
+	Sketch    *Sketch // if set, each contig is added to it as Next reaches it.
 }
 
-func (km *Kmers) load() {
+func (km *Kmers) load() error {
 	file, err := os.Open(km.src)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	defer file.Close()
 
@@ -42,53 +42,77 @@ func (km *Kmers) load() {
 			seq = append(seq, []byte(s)...)
 		}
 	}
-	km.Sequences = append(km.Sequences, string(seq))
-	if len(km.Sequences) == 0 {
-		log.Fatal("Couldn't load any sequences from file.")
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading %s: %v", km.src, err)
+	}
+	if len(km.Headers) == 0 {
+		return fmt.Errorf("couldn't load any sequences from %s", km.src)
 	}
+	km.Sequences = append(km.Sequences, string(seq))
+	return nil
 }
 
-// New creates a new Kmere struct.
-func New(s string) *Kmers {
+// Load creates a new Kmers struct from a FASTA file, returning an error if
+// it can't be read or holds no sequences.
+func Load(s string) (*Kmers, error) {
 	km := &Kmers{
 		src: s,
 		li:  0,
 		pi:  0,
 		K:   11,
 	}
-	km.load()
-	return km
+	if err := km.load(); err != nil {
+		return nil, err
+	}
+	return km, nil
 }
 
 // HasNext returns true if the source file still has kmers.
 func (km *Kmers) HasNext() bool {
-	lastOfSequences := km.li == len(km.Sequences)-1
-	endOfSeq := km.pi+km.K > len(km.Sequences[km.li])
-	return !(lastOfSequences && endOfSeq)
+	if km.ContigHasNext() {
+		return true
+	}
+	for i := km.li + 1; i < len(km.Sequences); i++ {
+		if km.long(i) {
+			return true
+		}
+	}
+	return false
 }
 
 // ContigHasNext returns true if the current contig in a source file still has kmers.
 func (km *Kmers) ContigHasNext() bool {
+	if km.li >= len(km.Sequences) || !km.long(km.li) {
+		return false
+	}
 	endOfSeq := km.pi+km.K > len(km.Sequences[km.li])
 	return !endOfSeq
 }
 
+// long returns true if contig i is longer than k, so it has an edge. Shorter
+// contigs are skipped.
+func (km *Kmers) long(i int) bool {
+	return len(km.Sequences[i]) > km.K
+}
+
 // Next emits the next kmer.
 func (km *Kmers) Next() (string, string) {
-	// Done.
+	// Done, once any short contigs left at the end are skipped.
 	if !km.HasNext() {
+		for ; km.li < len(km.Sequences); km.li++ {
+			if !km.long(km.li) {
+				log.Printf("WARNING: contig %s is shorter than the chosen k-value of %v. Skipping contig.", km.Headers[km.li], km.K)
+			}
+		}
 		return "", ""
 	}
 
-	// Move to next sequence.
-	if !km.ContigHasNext() {
-		km.li++
-		km.pi = 0
-	}
-
-	// K is greater than the size of the contig.
-	if km.K > len(km.Sequences[km.li])-1 {
-		log.Printf("WARNING: contig %s is shorter than the chosen k-value of %v. Skipping contig.", km.Headers[km.li], km.K)
+	// Move to the next contig long enough to have kmers; HasNext ensures
+	// there is one.
+	for !km.ContigHasNext() {
+		if !km.long(km.li) {
+			log.Printf("WARNING: contig %s is shorter than the chosen k-value of %v. Skipping contig.", km.Headers[km.li], km.K)
+		}
 		km.li++
 		km.pi = 0
 	}
@@ -96,11 +120,60 @@ func (km *Kmers) Next() (string, string) {
 	// Fasta header.
 	header := km.Headers[km.li]
 	// Slice of the sequence.
-	// log.Printf("%v, %v, %v, %v", km.li, km.pi, lastOfSequences, endOfSeq)
 	sl := km.Sequences[km.li][km.pi : km.pi+km.K]
+	// The sketch has its own k, so it's given the whole contig.
+	if km.Sketch != nil && km.pi == 0 {
+		km.Sketch.AddSequence(km.Sequences[km.li])
+	}
 
 	// Increment.
 	km.pi++
 
 	return header, sl
 }
+
+// NewFromSequences creates a new Kmers struct from sequences already held in
+// memory, such as those spelled out from a GFA file. Without any sequences it
+// has no k-mers.
+func NewFromSequences(headers []string, sequences []string) *Kmers {
+	km := &Kmers{
+		Headers:   headers,
+		Sequences: sequences,
+		li:        0,
+		pi:        0,
+		K:         11,
+	}
+	return km
+}
+
+// ReverseComplement returns the reverse complement of a nucleotide sequence.
+// Characters other than ACGT (in either case) are kept as-is.
+func ReverseComplement(s string) string {
+	rc := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		rc[len(s)-1-i] = complement(s[i])
+	}
+	return string(rc)
+}
+
+func complement(b byte) byte {
+	switch b {
+	case 'A':
+		return 'T'
+	case 'C':
+		return 'G'
+	case 'G':
+		return 'C'
+	case 'T':
+		return 'A'
+	case 'a':
+		return 't'
+	case 'c':
+		return 'g'
+	case 'g':
+		return 'c'
+	case 't':
+		return 'a'
+	}
+	return b
+}
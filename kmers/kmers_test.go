@@ -0,0 +1,26 @@
+package kmers
+
+import (
+	"fmt"
+)
+
+func ExampleKmers_Next() {
+	// Contigs no longer than k are skipped wherever they are, even in a run
+	// or at the end.
+	km := NewFromSequences(
+		[]string{">a", ">b", ">c", ">d", ">e", ">f"},
+		[]string{"GAT", "GATTAC", "GATTA", "GA", "CCATGG", "TAC"},
+	)
+	km.K = 5
+	for km.HasNext() {
+		fmt.Println(km.Next())
+	}
+	header, kmer := km.Next()
+	fmt.Printf("%q %q\n", header, kmer)
+	// Output:
+	// >b GATTA
+	// >b ATTAC
+	// >e CCATG
+	// >e CATGG
+	// "" ""
+}
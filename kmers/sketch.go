@@ -0,0 +1,129 @@
+package kmers
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// DefaultSketchK, DefaultSketchSize and DefaultSketchSeed match the defaults
+// of Mash.
+const (
+	DefaultSketchK    = 21
+	DefaultSketchSize = 1000
+	DefaultSketchSeed = 42
+)
+
+// Sketch is a bottom-k MinHash sketch of the canonical k-mers of a genome,
+// hashed as Mash does: the first 64 bits of MurmurHash3_x64_128, or
+// MurmurHash3_x86_32 for k of 16 or less. Hashes holds the smallest Size
+// distinct hashes. K is the sketch's own, independent of the graph's.
+type Sketch struct {
+	K      int      `json:"k"`
+	Size   int      `json:"size"`
+	Seed   uint32   `json:"seed"`
+	Hashes []uint64 `json:"hashes"`
+}
+
+// NewSketch creates an empty sketch.
+func NewSketch(k int, size int, seed uint32) *Sketch {
+	return &Sketch{
+		K:    k,
+		Size: size,
+		Seed: seed,
+	}
+}
+
+// AddSequence adds every k-mer of a sequence. As in Mash, the sequence is
+// upper-cased and k-mers with bases other than ACGT are skipped.
+func (s *Sketch) AddSequence(seq string) {
+	seq = strings.ToUpper(seq)
+	for i := 0; i+s.K <= len(seq); i++ {
+		kmer := seq[i : i+s.K]
+		if strings.Trim(kmer, "ACGT") != "" {
+			continue
+		}
+		s.Add(kmer)
+	}
+}
+
+// hash returns the hash Mash gives a canonical k-mer.
+func (s *Sketch) hash(kmer string) uint64 {
+	if s.K <= 16 {
+		return uint64(murmur3x86([]byte(kmer), s.Seed))
+	}
+	return murmur3([]byte(kmer), s.Seed)
+}
+
+// Add hashes a k-mer, keeping it if it's among the smallest hashes seen.
+func (s *Sketch) Add(kmer string) {
+	if len(kmer) != s.K {
+		return
+	}
+	if rc := ReverseComplement(kmer); rc < kmer {
+		kmer = rc
+	}
+	h := s.hash(kmer)
+	n := len(s.Hashes)
+	if n == s.Size && h >= s.Hashes[n-1] {
+		return
+	}
+	i := sort.Search(n, func(i int) bool { return s.Hashes[i] >= h })
+	if i < n && s.Hashes[i] == h {
+		return
+	}
+	s.Hashes = append(s.Hashes, 0)
+	copy(s.Hashes[i+1:], s.Hashes[i:])
+	s.Hashes[i] = h
+	if len(s.Hashes) > s.Size {
+		s.Hashes = s.Hashes[:s.Size]
+	}
+}
+
+// Jaccard estimates the Jaccard index of two sketches from the smallest Size
+// hashes of their union, as Mash does.
+func (s *Sketch) Jaccard(o *Sketch) float64 {
+	size := s.Size
+	if o.Size < size {
+		size = o.Size
+	}
+	i, j, union, shared := 0, 0, 0, 0
+	for union < size && i < len(s.Hashes) && j < len(o.Hashes) {
+		switch {
+		case s.Hashes[i] < o.Hashes[j]:
+			i++
+		case s.Hashes[i] > o.Hashes[j]:
+			j++
+		default:
+			shared++
+			i++
+			j++
+		}
+		union++
+	}
+	for union < size && i < len(s.Hashes) {
+		i++
+		union++
+	}
+	for union < size && j < len(o.Hashes) {
+		j++
+		union++
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Distance returns the Mash distance between two sketches, an estimate of the
+// per-base mutation rate from their Jaccard index.
+func (s *Sketch) Distance(o *Sketch) float64 {
+	j := s.Jaccard(o)
+	switch j {
+	case 0:
+		return 1
+	case 1:
+		return 0
+	}
+	return -math.Log(2*j/(1+j)) / float64(s.K)
+}
@@ -0,0 +1,40 @@
+package kmers
+
+import (
+	"fmt"
+)
+
+func ExampleSketch_Distance() {
+	// Reference values of MurmurHash3, which Mash hashes k-mers with.
+	fmt.Printf("%x\n", murmur3([]byte("hello"), 0))
+	fmt.Printf("%08x %08x\n", murmur3x86([]byte("hello"), 0), murmur3x86([]byte("The quick brown fox jumps over the lazy dog"), 0x9747b28c))
+
+	// Short k-mers are hashed to 32 bits and long ones to 64, in canonical
+	// form.
+	short := NewSketch(5, 10, DefaultSketchSeed)
+	short.Add("TTACA")
+	long := NewSketch(DefaultSketchK, 10, DefaultSketchSeed)
+	long.AddSequence("gattacagcgtccatggtttaNaccggt")
+	fmt.Println(short.Hashes[0] == uint64(murmur3x86([]byte("TGTAA"), DefaultSketchSeed)), len(long.Hashes))
+
+	a := NewFromSequences([]string{">a"}, []string{"GATTACAGCGTCCATGGTTTAAACCGGT"})
+	a.K = 5
+	a.Sketch = NewSketch(5, 10, DefaultSketchSeed)
+	for a.HasNext() {
+		a.Next()
+	}
+	b := NewSketch(5, 10, DefaultSketchSeed)
+	for _, kmer := range []string{"GATTA", "ATTAC", "TTACA", "TACAG", "ACAGC", "CAGCT", "AGCTT"} {
+		b.Add(ReverseComplement(kmer))
+	}
+	fmt.Println(len(a.Sketch.Hashes), len(b.Hashes))
+	fmt.Printf("%.2f %.4f\n", a.Sketch.Jaccard(b), a.Sketch.Distance(b))
+	fmt.Printf("%.2f %.4f\n", a.Sketch.Jaccard(a.Sketch), a.Sketch.Distance(a.Sketch))
+	// Output:
+	// cbd8a7b341bd9b02
+	// 248bfa47 2fa826cd
+	// true 1
+	// 10 7
+	// 0.40 0.1119
+	// 1.00 0.0000
+}
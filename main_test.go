@@ -15,14 +15,22 @@ func BenchmarkNew(*testing.B) {
 	pangenome.NewGraph()
 }
 func ExampleKmers() {
-	km := kmers.New("testdata/172.fa")
+	km, err := kmers.Load("testdata/172.fa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	header, _ := km.Next()
 	fmt.Println(header)
 	// Output: >gi|1062504329|gb|CP014670.1| Escherichia coli strain CFSAN004177, complete genome
 }
 
 func ExampleKmersNext() {
-	km := kmers.New("testdata/172.fa")
+	km, err := kmers.Load("testdata/172.fa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	header, kmer := km.Next()
 	fmt.Println(header)
 	fmt.Println(kmer)
@@ -37,7 +45,11 @@ func ExampleKmersNext() {
 }
 
 func ExampleKmersIndex() {
-	km := kmers.New("testdata/ECI-2866_lcl.fasta")
+	km, err := kmers.Load("testdata/ECI-2866_lcl.fasta")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Println(len(km.Headers))
 	fmt.Println(len(km.Sequences))
 	fmt.Println(len(km.Sequences) == len(km.Headers))
@@ -67,7 +79,11 @@ func ExampleKmersIndex() {
 
 // ExampleKmersIndexEnd checks end case.
 func ExampleKmersIndexEnd() {
-	km := kmers.New("testdata/ECI-2866_lcl.fasta")
+	km, err := kmers.Load("testdata/ECI-2866_lcl.fasta")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	var header, kmer string
 	h, k := km.Next()
 	for ; h != ""; h, k = km.Next() {
@@ -83,7 +99,11 @@ func ExampleKmersIndexEnd() {
 
 // ExampleKmersIndexDiff checks last of a contig before switching.
 func ExampleKmersIndexDiff() {
-	km := kmers.New("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	km, err := kmers.Load("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	var header, kmer string
 	h, k := km.Next()
 	header, kmer = h, k
@@ -108,9 +128,13 @@ func ExampleNewNode() {
 	// Make sure we're using a clean database.
 	g.DropAll(contextMain)
 
-	km := kmers.New("testdata/ECI-2523.fsa")
+	km, err := kmers.Load("testdata/ECI-2523.fsa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	_, seq := km.Next()
-	_, err := g.CreateNode(seq, contextMain)
+	_, err = g.CreateNode(seq, contextMain)
 	fmt.Println(err)
 	// Output:
 	// <nil>
@@ -126,11 +150,15 @@ func ExampleGetNode() {
 	// Make sure we're using a clean database.
 	g.DropAll(contextMain)
 
-	km := kmers.New("testdata/ECI-2523.fsa")
+	km, err := kmers.Load("testdata/ECI-2523.fsa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	_, seq := km.Next()
 	uid, err := g.CreateNode(seq, contextMain)
 	fmt.Println(err)
-	uidRetrieved, b := g.GetNode(seq, contextMain)
+	uidRetrieved, b, _ := g.GetNode(seq, contextMain)
 	log.Println(uid)
 	log.Println(uidRetrieved)
 	fmt.Println(b)
@@ -151,7 +179,11 @@ func ExampleNewNodes() {
 	// Make sure we're using a clean database.
 	g.DropAll(contextMain)
 
-	km := kmers.New("testdata/ECI-2523.fsa")
+	km, err := kmers.Load("testdata/ECI-2523.fsa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	_, seq := km.Next()
 	uid1, _ := g.CreateNode(seq, contextMain)
@@ -179,7 +211,11 @@ func BenchmarkNewNode(b *testing.B) {
 	// Make sure we're using a clean database.
 	g.DropAll(contextMain)
 
-	km := kmers.New("testdata/ECI-2523.fsa")
+	km, err := kmers.Load("testdata/ECI-2523.fsa")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -194,7 +230,11 @@ func ExampleNewEdge() {
 
 	g := pangenome.NewGraph()
 	defer g.Close()
-	km := kmers.New("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	km, err := kmers.Load("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	_, seq1 := km.Next()
 	_, seq2 := km.Next()
 	uid1, err := g.CreateNode(seq1, contextMain)
@@ -224,11 +264,15 @@ func BenchmarkCreateAll(b *testing.B) {
 	g := pangenome.NewGraph()
 	log.Println("Graph created OK.")
 	defer g.Close()
-	km := kmers.New("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	km, err := kmers.Load("testdata/GCA_900015695.1_ED647_contigs_genomic.fna")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	log.Println("Kmers created OK.")
 	b.ResetTimer()
 	log.Println("Starting Node/Edge creation.")
-	g.CreateAll(km, contextMain)
+	g.CreateAll("ED647", km, contextMain)
 	log.Println("Nodes/Edges created OK.")
 }
 
@@ -238,8 +282,12 @@ func ExampleCreateAll() {
 
 	g := pangenome.NewGraph()
 	defer g.Close()
-	km := kmers.New("testdata/GCA_900015695.1_ED647_contigs_genomic_SHORTENED.fna")
-	b, _ := g.CreateAll(km, contextMain)
+	km, err := kmers.Load("testdata/GCA_900015695.1_ED647_contigs_genomic_SHORTENED.fna")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	b, _ := g.CreateAll("ED647", km, contextMain)
 	fmt.Println(b)
 	// Output:
 	// true
@@ -298,19 +346,23 @@ func ExampleSetKVSliceUint64Genome() {
 	g.DropAll(contextMain)
 	log.Println("Dropped all values in Dgraph.")
 
-	km := kmers.New("testdata/GCA_900015695.1_ED647_contigs_genomic_SHORTENED.fna")
+	km, err := kmers.Load("testdata/GCA_900015695.1_ED647_contigs_genomic_SHORTENED.fna")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	log.Println("Created km OK.")
 
-	b, _ := g.CreateAll(km, contextMain)
+	b, _ := g.CreateAll("ED647", km, contextMain)
 	log.Println("Done creating all nodes/edges.")
 	fmt.Println(b)
 
 	log.Println("Retrieving slice 1...")
-	v1, _ := g.GetKVSliceUint64(">FAVS01000269.1 Escherichia coli strain ED647 genome assembly, contig: out_269, whole genome shotgun sequence")
+	v1, _ := g.GetKVSliceUint64("path/ED647/>FAVS01000269.1 Escherichia coli strain ED647 genome assembly, contig: out_269, whole genome shotgun sequence")
 	log.Println("Retrieving slice 2...")
-	v2, _ := g.GetKVSliceUint64(">FAVS01000267.1 Escherichia coli strain ED647 genome assembly, contig: out_267, whole genome shotgun sequence")
+	v2, _ := g.GetKVSliceUint64("path/ED647/>FAVS01000267.1 Escherichia coli strain ED647 genome assembly, contig: out_267, whole genome shotgun sequence")
 	log.Println("Retrieving slice 3...")
-	v3, _ := g.GetKVSliceUint64(">FAVS01000266.1 Escherichia coli strain ED647 genome assembly, contig: out_266, whole genome shotgun sequence")
+	v3, _ := g.GetKVSliceUint64("path/ED647/>FAVS01000266.1 Escherichia coli strain ED647 genome assembly, contig: out_266, whole genome shotgun sequence")
 	fmt.Println(v1)
 	fmt.Println(v2)
 	fmt.Println(v3)
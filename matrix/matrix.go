@@ -0,0 +1,154 @@
+// Package matrix writes binary presence/absence matrices of samples by features
+// for downstream GWAS and machine learning tools.
+package matrix
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Matrix is a sparse binary matrix stored by column: Columns[j] holds the
+// sorted indices of the rows in which feature j is present.
+type Matrix struct {
+	Rows    []string
+	Cols    []string
+	Columns [][]int
+}
+
+// New creates an empty matrix with the given row names.
+func New(rows []string) *Matrix {
+	return &Matrix{
+		Rows: rows,
+	}
+}
+
+// Add appends a feature present in the given rows.
+func (m *Matrix) Add(name string, rows []int) {
+	sorted := append([]int{}, rows...)
+	sort.Ints(sorted)
+	m.Cols = append(m.Cols, name)
+	m.Columns = append(m.Columns, sorted)
+}
+
+// Filter returns a matrix of only the features present in at least min and at
+// most max of the rows, both as fractions.
+func (m *Matrix) Filter(min float64, max float64) *Matrix {
+	f := New(m.Rows)
+	for j, rows := range m.Columns {
+		freq := float64(len(rows)) / float64(len(m.Rows))
+		if freq < min || freq > max {
+			continue
+		}
+		f.Cols = append(f.Cols, m.Cols[j])
+		f.Columns = append(f.Columns, rows)
+	}
+	return f
+}
+
+// Dedup returns a matrix with one feature per distinct presence pattern. The
+// first feature with a pattern represents it, and groups maps that feature to
+// all features sharing the pattern, in order.
+func (m *Matrix) Dedup() (*Matrix, map[string][]string) {
+	d := New(m.Rows)
+	groups := make(map[string][]string)
+	seen := make(map[string]string)
+	for j, rows := range m.Columns {
+		pattern := fmt.Sprint(rows)
+		rep, ok := seen[pattern]
+		if !ok {
+			rep = m.Cols[j]
+			seen[pattern] = rep
+			d.Cols = append(d.Cols, rep)
+			d.Columns = append(d.Columns, rows)
+		}
+		groups[rep] = append(groups[rep], m.Cols[j])
+	}
+	return d, groups
+}
+
+// Dense returns the matrix as rows of 0/1 values.
+func (m *Matrix) Dense() [][]byte {
+	dense := make([][]byte, len(m.Rows))
+	for i := range dense {
+		dense[i] = make([]byte, len(m.Cols))
+	}
+	for j, rows := range m.Columns {
+		for _, i := range rows {
+			dense[i][j] = 1
+		}
+	}
+	return dense
+}
+
+// CSR returns the row pointers and column indices of the matrix in compressed
+// sparse row form.
+func (m *Matrix) CSR() ([]int, []int) {
+	byRow := make([][]int, len(m.Rows))
+	for j, rows := range m.Columns {
+		for _, i := range rows {
+			byRow[i] = append(byRow[i], j)
+		}
+	}
+	indptr := make([]int, 0, len(m.Rows)+1)
+	var indices []int
+	indptr = append(indptr, 0)
+	for _, cols := range byRow {
+		indices = append(indices, cols...)
+		indptr = append(indptr, len(indices))
+	}
+	return indptr, indices
+}
+
+// WriteTSV writes a dense tab-separated matrix with samples as rows and a
+// header of feature names.
+func (m *Matrix) WriteTSV(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "sample\t%s\n", strings.Join(m.Cols, "\t")); err != nil {
+		return err
+	}
+	for i, row := range m.Dense() {
+		var sb strings.Builder
+		sb.WriteString(m.Rows[i])
+		for _, v := range row {
+			sb.WriteByte('\t')
+			sb.WriteByte('0' + v)
+		}
+		sb.WriteByte('\n')
+		if _, err := io.WriteString(w, sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMatrixMarket writes the matrix in sparse Matrix Market coordinate
+// format, with 1-based indices.
+func (m *Matrix) WriteMatrixMarket(w io.Writer) error {
+	nnz := 0
+	for _, rows := range m.Columns {
+		nnz += len(rows)
+	}
+	_, err := fmt.Fprintf(w, "%%%%MatrixMarket matrix coordinate integer general\n%v %v %v\n", len(m.Rows), len(m.Cols), nnz)
+	if err != nil {
+		return err
+	}
+	for j, rows := range m.Columns {
+		for _, i := range rows {
+			if _, err := fmt.Fprintf(w, "%v %v 1\n", i+1, j+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteRows writes the row names, one per line.
+func (m *Matrix) WriteRows(w io.Writer) error {
+	for _, row := range m.Rows {
+		if _, err := fmt.Fprintln(w, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package matrix
+
+import (
+	"fmt"
+	"os"
+)
+
+func ExampleMatrix_Dedup() {
+	m := New([]string{"a", "b", "c"})
+	m.Add("f1", []int{0, 1})
+	m.Add("f2", []int{0, 1, 2})
+	m.Add("f3", []int{1, 0})
+	m.Add("f4", []int{2})
+	m, groups := m.Filter(0.5, 0.9).Dedup()
+	m.WriteTSV(os.Stdout)
+	m.WriteMatrixMarket(os.Stdout)
+	fmt.Println(groups)
+	// Output:
+	// sample	f1
+	// a	1
+	// b	1
+	// c	0
+	// %%MatrixMarket matrix coordinate integer general
+	// 3 1 2
+	// 1 1 1
+	// 2 1 1
+	// map[f1:[f1 f3]]
+}
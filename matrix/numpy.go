@@ -0,0 +1,98 @@
+package matrix
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// npyHeader builds a version 1.0 .npy header for the given dtype and shape.
+// The header is padded with spaces so the data starts on a 64 byte boundary.
+func npyHeader(descr string, shape ...int) []byte {
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = fmt.Sprint(d)
+	}
+	s := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		s += ","
+	}
+	dict := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, s)
+
+	// magic (6) + version (2) + header length (2) + dict + newline.
+	pad := 64 - (10+len(dict)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	dict += strings.Repeat(" ", pad) + "\n"
+
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY")
+	buf.Write([]byte{1, 0})
+	binary.Write(&buf, binary.LittleEndian, uint16(len(dict)))
+	buf.WriteString(dict)
+	return buf.Bytes()
+}
+
+// WriteNpy writes the matrix as a dense uint8 NumPy array of shape
+// (samples, features).
+func (m *Matrix) WriteNpy(w io.Writer) error {
+	if _, err := w.Write(npyHeader("|u1", len(m.Rows), len(m.Cols))); err != nil {
+		return err
+	}
+	for _, row := range m.Dense() {
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteNpz writes the matrix as a compressed sparse row archive which can be
+// read with scipy.sparse.load_npz.
+func (m *Matrix) WriteNpz(w io.Writer) error {
+	indptr, indices := m.CSR()
+
+	z := zip.NewWriter(w)
+	arrays := []struct {
+		name string
+		data []byte
+	}{
+		{"indices.npy", npyInt32(indices)},
+		{"indptr.npy", npyInt32(indptr)},
+		{"format.npy", append(npyHeader("|S3"), "csr"...)},
+		{"shape.npy", npyInt64([]int{len(m.Rows), len(m.Cols)})},
+		{"data.npy", append(npyHeader("|u1", len(indices)), bytes.Repeat([]byte{1}, len(indices))...)},
+	}
+	for _, a := range arrays {
+		f, err := z.Create(a.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(a.data); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func npyInt32(values []int) []byte {
+	var buf bytes.Buffer
+	buf.Write(npyHeader("<i4", len(values)))
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, int32(v))
+	}
+	return buf.Bytes()
+}
+
+func npyInt64(values []int) []byte {
+	var buf bytes.Buffer
+	buf.Write(npyHeader("<i8", len(values)))
+	for _, v := range values {
+		binary.Write(&buf, binary.LittleEndian, int64(v))
+	}
+	return buf.Bytes()
+}
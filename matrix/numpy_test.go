@@ -0,0 +1,99 @@
+package matrix
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// readNpy checks the magic and alignment of a version 1.0 .npy file and
+// returns its header dict and data.
+func readNpy(npy []byte) (string, []byte, error) {
+	if len(npy) < 10 || string(npy[:6]) != "\x93NUMPY" || npy[6] != 1 || npy[7] != 0 {
+		return "", nil, fmt.Errorf("bad magic % x", npy[:8])
+	}
+	n := 10 + int(binary.LittleEndian.Uint16(npy[8:]))
+	if n%64 != 0 || n > len(npy) || npy[n-1] != '\n' {
+		return "", nil, fmt.Errorf("bad header length %v", n)
+	}
+	return strings.TrimSpace(string(npy[10:n])), npy[n:], nil
+}
+
+func testMatrix() *Matrix {
+	m := New([]string{"a", "b", "c"})
+	m.Add("f1", []int{0, 1})
+	m.Add("f2", []int{2})
+	return m
+}
+
+func ExampleMatrix_WriteNpy() {
+	var buf bytes.Buffer
+	if err := testMatrix().WriteNpy(&buf); err != nil {
+		fmt.Println(err)
+		return
+	}
+	dict, data, err := readNpy(buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(dict)
+	fmt.Println(data)
+	// Output:
+	// {'descr': '|u1', 'fortran_order': False, 'shape': (3, 2), }
+	// [1 0 1 0 0 1]
+}
+
+func ExampleMatrix_WriteNpz() {
+	var buf bytes.Buffer
+	if err := testMatrix().WriteNpz(&buf); err != nil {
+		fmt.Println(err)
+		return
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		npy, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		dict, data, err := readNpy(npy)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		var values interface{} = data
+		switch {
+		case strings.Contains(dict, "'<i4'"):
+			v := make([]int32, len(data)/4)
+			binary.Read(bytes.NewReader(data), binary.LittleEndian, v)
+			values = v
+		case strings.Contains(dict, "'<i8'"):
+			v := make([]int64, len(data)/8)
+			binary.Read(bytes.NewReader(data), binary.LittleEndian, v)
+			values = v
+		case strings.Contains(dict, "'|S3'"):
+			values = string(data)
+		}
+		fmt.Println(f.Name, dict, values)
+	}
+	// Output:
+	// indices.npy {'descr': '<i4', 'fortran_order': False, 'shape': (3,), } [0 0 1]
+	// indptr.npy {'descr': '<i4', 'fortran_order': False, 'shape': (4,), } [0 1 2 3]
+	// format.npy {'descr': '|S3', 'fortran_order': False, 'shape': (), } csr
+	// shape.npy {'descr': '<i8', 'fortran_order': False, 'shape': (2,), } [3 2]
+	// data.npy {'descr': '|u1', 'fortran_order': False, 'shape': (3,), } [1 1 1]
+}
@@ -0,0 +1,227 @@
+package pangenome
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/dgraph-io/badger"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+const featurePrefix = "features/" // features/<sample>: annotated features.
+
+// Feature is an annotated region of a contig, such as a gene or CDS. Start and
+// End are 0-based and half-open. Nodes are the k-mers of the contig's path
+// lying within the feature, filled in once the sample is in the graph.
+type Feature struct {
+	Sample  string   `json:"sample,omitempty"`
+	Contig  string   `json:"contig"`
+	Type    string   `json:"type"`
+	ID      string   `json:"id,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Product string   `json:"product,omitempty"`
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+	Strand  string   `json:"strand"`
+	Nodes   []uint64 `json:"nodes,omitempty"`
+}
+
+// Label is the name a feature is known by: its Name, else its ID.
+func (f *Feature) Label() string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return f.ID
+}
+
+// Annotated is a genome read from an annotation file, with contigs keyed by
+// their fasta-style headers.
+type Annotated struct {
+	Headers   []string
+	Sequences []string
+	Features  []*Feature
+}
+
+// AddAnnotated adds an annotated genome as a sample and attaches its features
+// to the nodes on their paths.
+func (g *Graph) AddAnnotated(name string, a *Annotated, contextMain context.Context) (bool, error) {
+	km := kmers.NewFromSequences(a.Headers, a.Sequences)
+	km.K = g.K
+	if _, err := g.AddGenome(name, km, contextMain); err != nil {
+		return false, err
+	}
+	if err := g.Annotate(name, a.Features); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Annotate attaches features to an existing sample, replacing any it had.
+// Features on contigs without a stored path are dropped.
+func (g *Graph) Annotate(sample string, features []*Feature) error {
+	paths := make(map[string][]uint64)
+	var kept []*Feature
+	for _, f := range features {
+		path, ok := paths[f.Contig]
+		if !ok {
+			p, err := g.ContigPath(sample, f.Contig)
+			if err != nil && err != badger.ErrKeyNotFound {
+				return err
+			}
+			path = p
+			paths[f.Contig] = path
+		}
+		if len(path) == 0 {
+			continue
+		}
+		f.Sample = sample
+		f.Nodes = featureNodes(path, f, g.K)
+		kept = append(kept, f)
+	}
+	buf, err := json.Marshal(kept)
+	if err != nil {
+		return err
+	}
+	return g.bd.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(featurePrefix+sample), buf)
+	})
+}
+
+// Features returns the features of a sample, or nil if it has none.
+func (g *Graph) Features(sample string) ([]*Feature, error) {
+	var features []*Feature
+	err := g.bd.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(featurePrefix + sample))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &features)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return features, nil
+}
+
+// featureNodes returns the k-mers of a path within a feature, or those
+// overlapping it if it's shorter than k.
+func featureNodes(path []uint64, f *Feature, k int) []uint64 {
+	start, end := f.Start, f.End-k+1
+	if end <= start {
+		start, end = f.Start-k+1, f.End
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(path) {
+		end = len(path)
+	}
+	if end <= start {
+		return nil
+	}
+	return append([]uint64{}, path[start:end]...)
+}
+
+// Annotate attaches features to a sample already in the index, finding their
+// nodes from its contig paths. Nothing is written to the store.
+func (idx *Index) Annotate(sample string, features []*Feature) {
+	i := -1
+	for j, name := range idx.Samples {
+		if name == sample {
+			i = j
+		}
+	}
+	if i == -1 {
+		return
+	}
+	var kept []*Feature
+	for _, f := range features {
+		path := idx.Path(i, f.Contig)
+		if len(path) == 0 {
+			continue
+		}
+		f.Sample = sample
+		f.Nodes = featureNodes(path, f, idx.K)
+		kept = append(kept, f)
+	}
+	idx.AddFeatures(kept)
+}
+
+// AddFeatures adds annotated features to the index.
+func (idx *Index) AddFeatures(features []*Feature) {
+	idx.Features = append(idx.Features, features...)
+	idx.labels = nil
+}
+
+// buildLabels fills in the node: features map the first time it's needed.
+func (idx *Index) buildLabels() {
+	if idx.labels != nil {
+		return
+	}
+	idx.labels = make(map[uint64][]*Feature)
+	for _, f := range idx.Features {
+		for _, uid := range f.Nodes {
+			idx.labels[uid] = append(idx.labels[uid], f)
+		}
+	}
+}
+
+// FeatureNodes returns every node in features with the given name or id, in
+// any sample, sorted.
+func (idx *Index) FeatureNodes(name string) []uint64 {
+	seen := make(map[uint64]bool)
+	var nodes []uint64
+	for _, f := range idx.Features {
+		if f.Name != name && f.ID != name {
+			continue
+		}
+		for _, uid := range f.Nodes {
+			if !seen[uid] {
+				seen[uid] = true
+				nodes = append(nodes, uid)
+			}
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes
+}
+
+// FeaturesAt returns the features containing any of the nodes, in the order
+// they were added.
+func (idx *Index) FeaturesAt(nodes []uint64) []*Feature {
+	idx.buildLabels()
+	hit := make(map[*Feature]bool)
+	for _, uid := range nodes {
+		for _, f := range idx.labels[uid] {
+			hit[f] = true
+		}
+	}
+	var features []*Feature
+	for _, f := range idx.Features {
+		if hit[f] {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// Labels returns the distinct, sorted labels of the features containing any
+// of the nodes.
+func (idx *Index) Labels(nodes []uint64) []string {
+	seen := make(map[string]bool)
+	var labels []string
+	for _, f := range idx.FeaturesAt(nodes) {
+		if l := f.Label(); l != "" && !seen[l] {
+			seen[l] = true
+			labels = append(labels, l)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
@@ -0,0 +1,85 @@
+package pangenome
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func ExampleReadGFF3() {
+	gff := "##gff-version 3\n" +
+		"ctg1\tProdigal\tregion\t1\t20\t.\t+\t.\tID=ctg1\n" +
+		"ctg1\tProdigal\tgene\t3\t10\t.\t+\t.\tID=g1;Name=stx2%20A\n" +
+		"ctg1\tProdigal\tCDS\t12\t20\t.\t-\t0\tID=c1;locus_tag=ABC_001\n" +
+		"##FASTA\n" +
+		">ctg1 plasmid\n" +
+		"GATTACAGCG\n" +
+		"TCCATGGTTT\n"
+	a, err := ReadGFF3(strings.NewReader(gff))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(a.Headers, a.Sequences)
+
+	idx := NewIndex(5)
+	idx.AddGenome("a", a.Headers, a.Sequences)
+	idx.Annotate("a", a.Features)
+	for _, f := range idx.Features {
+		fmt.Println(f.Type, f.Label(), f.Start, f.End, f.Strand, f.Nodes)
+	}
+	fmt.Println(idx.FeatureNodes("g1"), idx.Labels([]uint64{5, 12}))
+	WriteGFA(os.Stdout, idx, idx.NodeUnitigs()[:3])
+	// Output:
+	// [>ctg1 plasmid] [GATTACAGCGTCCATGGTTT]
+	// gene stx2 A 2 10 + [2 3 4 5]
+	// CDS ABC_001 11 20 - [11 12 13 14 15]
+	// [2 3 4 5] [ABC_001 stx2 A]
+	// H	VN:Z:1.0
+	// S	0	GATTA	LN:i:5	SC:i:1	RC:i:0
+	// S	1	ATTAC	LN:i:5	SC:i:1	RC:i:0
+	// S	2	TTACA	LN:i:5	SC:i:1	RC:i:0	gn:Z:stx2 A
+	// L	0	+	1	+	4M	RC:i:1
+	// L	1	+	2	+	4M	RC:i:1
+}
+
+func ExampleReadGFF3_unnamed() {
+	_, err := ReadGFF3(strings.NewReader("##gff-version 3\n##FASTA\n>\nGATTACA\n"))
+	fmt.Println(err)
+	// Output:
+	// gff line 3: FASTA header has no name
+}
+
+func ExampleReadGenBank() {
+	gbk := `LOCUS       ctg1                      20 bp    DNA     linear   BCT
+VERSION     NZ_1.1
+FEATURES             Location/Qualifiers
+     source          1..20
+                     /organism="Escherichia coli"
+     gene            complement(join(<3..6,8..>10))
+                     /gene="stx2"
+                     /locus_tag="ABC_001"
+                     /product="Shiga toxin 2
+                     subunit A"
+     CDS             join(12..14,
+                     16..18)
+                     /locus_tag="ABC_002"
+                     /product="Shiga toxin 2 subunit B"
+ORIGIN
+        1 gattacagcg tccatggttt
+//
+`
+	a, err := ReadGenBank(strings.NewReader(gbk))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(a.Headers, a.Sequences)
+	for _, f := range a.Features {
+		fmt.Println(f.Contig, f.Type, f.ID, f.Name, f.Start, f.End, f.Strand, f.Product)
+	}
+	// Output:
+	// [>NZ_1.1] [GATTACAGCGTCCATGGTTT]
+	// >NZ_1.1 gene ABC_001 stx2 2 10 - Shiga toxin 2 subunit A
+	// >NZ_1.1 CDS ABC_002 ABC_002 11 18 + Shiga toxin 2 subunit B
+}
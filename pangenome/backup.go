@@ -0,0 +1,593 @@
+package pangenome
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/badger"
+)
+
+// A backup is a gzipped tar of a manifest followed by three entries:
+// nodes.tsv (uid, sequence), edges.tsv (src, dst, weight) and kv, every
+// Badger key and value as records of a uvarint length and bytes. The
+// manifest describes the graph and carries the size and SHA-256 of each
+// entry, so an archive can be checked before anything is restored.
+const (
+	BackupFormat  = "prairiedog-backup"
+	BackupVersion = 1
+
+	manifestEntry = "manifest.json"
+	nodesEntry    = "nodes.tsv"
+	edgesEntry    = "edges.tsv"
+	kvEntry       = "kv"
+)
+
+// BackupConfig is the configuration a graph was built with.
+type BackupConfig struct {
+	K          int    `json:"k"`
+	Canonical  bool   `json:"canonical"` // nodes are canonical k-mers; never yet.
+	SketchK    int    `json:"sketch_k,omitempty"`
+	SketchSize int    `json:"sketch_size"`
+	SketchSeed uint32 `json:"sketch_seed"`
+}
+
+// BackupFile is an entry of a backup archive.
+type BackupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes a backup archive. Backend is embedded if the store
+// counted its own uids, else dgraph.
+type Manifest struct {
+	Format     string       `json:"format"`
+	Version    int          `json:"version"`
+	Prairiedog string       `json:"prairiedog"`
+	Created    time.Time    `json:"created"`
+	Backend    string       `json:"backend"`
+	Schema     int          `json:"schema"` // absent, so 0, before schema versions.
+	Config     BackupConfig `json:"config"`
+	Samples    []string     `json:"samples"`
+	Nodes      int          `json:"nodes"`
+	Edges      int          `json:"edges"`
+	Keys       int          `json:"keys"`
+	Files      []BackupFile `json:"files"`
+}
+
+// Backup writes the whole graph to w as a backup archive. Entries are staged
+// in a temporary directory so the manifest can lead with their checksums.
+func (g *Graph) Backup(w io.Writer) (*Manifest, error) {
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := g.StoredSchema()
+	if err != nil {
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "prairiedog-backup")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	m := &Manifest{
+		Format:     BackupFormat,
+		Version:    BackupVersion,
+		Prairiedog: Version,
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Backend:    "dgraph",
+		Schema:     schema,
+		Config: BackupConfig{
+			K:          g.K,
+			SketchK:    g.SketchK,
+			SketchSize: g.SketchSize,
+			SketchSeed: g.SketchSeed,
+		},
+		Samples: idx.Samples,
+	}
+	stage := func(name string, fn func(io.Writer) error) error {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		bw := bufio.NewWriter(io.MultiWriter(f, h))
+		if err := fn(bw); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, BackupFile{name, info.Size(), hex.EncodeToString(h.Sum(nil))})
+		return nil
+	}
+
+	nodes := idx.Nodes()
+	err = stage(nodesEntry, func(w io.Writer) error {
+		for _, uid := range nodes {
+			if _, err := fmt.Fprintf(w, "%v\t%s\n", uid, idx.Sequences[uid]); err != nil {
+				return err
+			}
+			m.Nodes++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = stage(edgesEntry, func(w io.Writer) error {
+		for _, src := range nodes {
+			dsts := make([]uint64, 0, len(idx.Forward[src]))
+			for dst := range idx.Forward[src] {
+				dsts = append(dsts, dst)
+			}
+			sort.Slice(dsts, func(i, j int) bool { return dsts[i] < dsts[j] })
+			for _, dst := range dsts {
+				if _, err := fmt.Fprintf(w, "%v\t%v\t%v\n", src, dst, idx.Forward[src][dst]); err != nil {
+					return err
+				}
+				m.Edges++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = stage(kvEntry, func(w io.Writer) error {
+		return g.bd.View(func(txn *badger.Txn) error {
+			it := txn.NewIterator(badger.DefaultIteratorOptions)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				item := it.Item()
+				if string(item.Key()) == nextUIDKey {
+					m.Backend = "embedded"
+				}
+				err := item.Value(func(val []byte) error {
+					return writeRecord(w, item.Key(), val)
+				})
+				if err != nil {
+					return err
+				}
+				m.Keys++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	buf, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	hdr := &tar.Header{Name: manifestEntry, Mode: 0644, Size: int64(len(buf)), ModTime: m.Created}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(buf); err != nil {
+		return nil, err
+	}
+	for _, file := range m.Files {
+		hdr := &tar.Header{Name: file.Name, Mode: 0644, Size: file.Size, ModTime: m.Created}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(filepath.Join(dir, file.Name))
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(tw, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeRecord writes a key and value, each prefixed by its uvarint length.
+func writeRecord(w io.Writer, key []byte, val []byte) error {
+	var n [binary.MaxVarintLen64]byte
+	for _, b := range [][]byte{key, val} {
+		if _, err := w.Write(n[:binary.PutUvarint(n[:], uint64(len(b)))]); err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRecord reads a record written by writeRecord, returning io.EOF once
+// there are none left.
+func readRecord(r *bufio.Reader) ([]byte, []byte, error) {
+	var kv [2][]byte
+	for i := range kv {
+		n, err := binary.ReadUvarint(r)
+		if err == io.EOF && i == 1 {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		kv[i] = make([]byte, n)
+		if _, err := io.ReadFull(r, kv[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return kv[0], kv[1], nil
+}
+
+// readBackup reads the archive at path, passing each entry after the
+// manifest to visit, if it's given, and failing if any entry doesn't match
+// its checksum.
+func readBackup(path string, visit func(name string, r io.Reader) error) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %v", err)
+	}
+	if hdr.Name != manifestEntry {
+		return nil, fmt.Errorf("archive starts with %s, not %s", hdr.Name, manifestEntry)
+	}
+	var m Manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return nil, fmt.Errorf("reading manifest: %v", err)
+	}
+	if m.Format != BackupFormat {
+		return nil, fmt.Errorf("archive is not a %s", BackupFormat)
+	}
+	if m.Version > BackupVersion {
+		return nil, fmt.Errorf("archive version %v is newer than %v", m.Version, BackupVersion)
+	}
+
+	files := make(map[string]BackupFile)
+	for _, file := range m.Files {
+		files[file.Name] = file
+	}
+	seen := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		file, ok := files[hdr.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not in the manifest", hdr.Name)
+		}
+		h := sha256.New()
+		r := io.TeeReader(tr, h)
+		if visit != nil {
+			if err := visit(hdr.Name, r); err != nil {
+				return nil, fmt.Errorf("%s: %v", hdr.Name, err)
+			}
+		}
+		if _, err := io.Copy(ioutil.Discard, r); err != nil {
+			return nil, err
+		}
+		if hdr.Size != file.Size || hex.EncodeToString(h.Sum(nil)) != file.SHA256 {
+			return nil, fmt.Errorf("%s: checksum mismatch", hdr.Name)
+		}
+		seen++
+	}
+	if seen != len(files) {
+		return nil, fmt.Errorf("archive has %v of %v entries", seen, len(files))
+	}
+	return &m, nil
+}
+
+// VerifyBackup checks every entry of the archive at path against its
+// checksum, returning the manifest.
+func VerifyBackup(path string) (*Manifest, error) {
+	return readBackup(path, nil)
+}
+
+// Restore loads the archive at path into an empty store, verifying it first
+// and taking K and the sketch settings from it. Embedded graphs get their
+// keys back as they were; Dgraph assigns new uids to the restored nodes, and
+// every key and value holding a uid is rewritten to match. Archives of older
+// schema versions are upgraded once restored. If ctx is cancelled, the store
+// is left partly restored and has to be emptied before trying again.
+func (g *Graph) Restore(path string, contextMain context.Context) (*Manifest, error) {
+	ctx, cancel := context.WithCancel(contextMain)
+	defer cancel()
+
+	empty, err := g.empty()
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, fmt.Errorf("store is not empty")
+	}
+
+	// Contig paths were keyed by their raw header before schema v2, so find
+	// them from the samples first.
+	paths := make(map[string]bool)
+	m, err := readBackup(path, func(name string, r io.Reader) error {
+		if name != kvEntry {
+			return nil
+		}
+		br := bufio.NewReader(r)
+		for {
+			key, val, err := readRecord(br)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(string(key), samplePrefix) {
+				continue
+			}
+			var headers []string
+			if err := json.Unmarshal(val, &headers); err != nil {
+				return err
+			}
+			for _, h := range headers {
+				paths[h] = true
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.K = m.Config.K
+	// Backups from before sketches had their own k don't record it.
+	if m.Config.SketchK != 0 {
+		g.SketchK = m.Config.SketchK
+	}
+	g.SketchSize = m.Config.SketchSize
+	g.SketchSeed = m.Config.SketchSeed
+
+	uids := make(map[uint64]uint64)
+	next := uint64(1)
+	nodes, edges, keys := 0, 0, 0
+	_, err = readBackup(path, func(name string, r io.Reader) error {
+		switch name {
+		case nodesEntry:
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				fields := strings.Split(scanner.Text(), "\t")
+				if len(fields) != 2 {
+					return fmt.Errorf("line %v has %v fields, expected 2", nodes+1, len(fields))
+				}
+				uid, err := strconv.ParseUint(fields[0], 10, 64)
+				if err != nil {
+					return err
+				}
+				uids[uid] = uid
+				if !g.embedded {
+					if uids[uid], err = g.CreateNode(fields[1], ctx); err != nil {
+						return err
+					}
+				}
+				if uid >= next {
+					next = uid + 1
+				}
+				nodes++
+			}
+			return scanner.Err()
+		case edgesEntry:
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				fields := strings.Split(scanner.Text(), "\t")
+				if len(fields) != 3 {
+					return fmt.Errorf("line %v has %v fields, expected 3", edges+1, len(fields))
+				}
+				if !g.embedded {
+					src, err := restoredUID(uids, fields[0])
+					if err != nil {
+						return err
+					}
+					dst, err := restoredUID(uids, fields[1])
+					if err != nil {
+						return err
+					}
+					if _, err := g.CreateEdge(src, dst, ctx); err != nil {
+						return err
+					}
+				}
+				edges++
+			}
+			return scanner.Err()
+		case kvEntry:
+			b := g.newBatch()
+			br := bufio.NewReader(r)
+			for {
+				key, val, err := readRecord(br)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				keys++
+				if string(key) == nextUIDKey {
+					continue
+				}
+				if !g.embedded {
+					if key, val, err = remapRecord(uids, paths, key, val); err != nil {
+						return err
+					}
+				}
+				if err := b.set(key, val); err != nil {
+					return err
+				}
+			}
+			return b.flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if nodes != m.Nodes || edges != m.Edges || keys != m.Keys {
+		return nil, fmt.Errorf("restored %v nodes, %v edges and %v keys, but the manifest lists %v, %v and %v",
+			nodes, edges, keys, m.Nodes, m.Edges, m.Keys)
+	}
+	if g.embedded {
+		// Carry on counting from the largest uid, which may have come from
+		// Dgraph.
+		if _, err := g.SetKVStr(nextUIDKey, strconv.FormatUint(next, 10)); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := g.SetKVInt(kKey, g.K); err != nil {
+		return nil, err
+	}
+	g.kRecorded = true
+	schema := m.Schema
+	if schema == 0 {
+		schema = 1
+	}
+	if schema > SchemaVersion {
+		return nil, newerSchemaError(schema)
+	}
+	if err := g.setSchema(schema); err != nil {
+		return nil, err
+	}
+	if _, err := g.Upgrade(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// restoredUID parses an archived uid and returns the uid it was restored as.
+func restoredUID(uids map[uint64]uint64, s string) (uint64, error) {
+	uid, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	restored, ok := uids[uid]
+	if !ok {
+		return 0, fmt.Errorf("uid %v is not a node", uid)
+	}
+	return restored, nil
+}
+
+// remapRecord rewrites the uids in a Badger key and value. paths holds the
+// raw headers of contig paths from before schema v2.
+func remapRecord(uids map[uint64]uint64, paths map[string]bool, key []byte, val []byte) ([]byte, []byte, error) {
+	remap := func(old []uint64) error {
+		for i, uid := range old {
+			restored, ok := uids[uid]
+			if !ok {
+				return fmt.Errorf("uid %v is not a node", uid)
+			}
+			old[i] = restored
+		}
+		return nil
+	}
+	remapString := func(s string) (string, error) {
+		uid, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		sl := []uint64{uid}
+		if err := remap(sl); err != nil {
+			return "", err
+		}
+		return strconv.FormatUint(sl[0], 10), nil
+	}
+
+	k := string(key)
+	switch {
+	case strings.HasPrefix(k, kmerPrefix):
+		s, err := remapString(string(val))
+		return key, []byte(s), err
+	case strings.HasPrefix(k, nodePrefix):
+		s, err := remapString(strings.TrimPrefix(k, nodePrefix))
+		return []byte(nodePrefix + s), val, err
+	case strings.HasPrefix(k, unitigOfPrefix):
+		s, err := remapString(strings.TrimPrefix(k, unitigOfPrefix))
+		return []byte(unitigOfPrefix + s), val, err
+	case strings.HasPrefix(k, unitigPrefix):
+		var u Unitig
+		if err := json.Unmarshal(val, &u); err != nil {
+			return nil, nil, err
+		}
+		if err := remap(u.Members); err != nil {
+			return nil, nil, err
+		}
+		buf, err := json.Marshal(u)
+		return key, buf, err
+	case strings.HasPrefix(k, pathPrefix) || paths[k]:
+		var path []uint64
+		if err := json.Unmarshal(val, &path); err != nil {
+			return nil, nil, err
+		}
+		if err := remap(path); err != nil {
+			return nil, nil, err
+		}
+		buf, err := json.Marshal(path)
+		return key, buf, err
+	case strings.HasPrefix(k, featurePrefix):
+		var features []*Feature
+		if err := json.Unmarshal(val, &features); err != nil {
+			return nil, nil, err
+		}
+		for _, f := range features {
+			if err := remap(f.Nodes); err != nil {
+				return nil, nil, err
+			}
+		}
+		buf, err := json.Marshal(features)
+		return key, buf, err
+	}
+	return key, val, nil
+}
@@ -0,0 +1,183 @@
+package pangenome
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+func openTestGraph(dir string) (*Graph, error) {
+	opts := DefaultOptions
+	opts.Dir = dir
+	opts.Embedded = true
+	opts.K = 5
+	return Open(opts)
+}
+
+func ExampleGraph_Restore() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(filepath.Join(dir, "a"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	for _, genome := range []struct{ name, seq string }{
+		{"a", "GATTACAGCGTCCATGG"},
+		{"b", "GATTACAGCTTCCATGG"},
+	} {
+		a := &Annotated{
+			Headers:   []string{">" + genome.name},
+			Sequences: []string{genome.seq},
+			Features:  []*Feature{{Contig: ">" + genome.name, Type: "CDS", Name: "gat", Start: 0, End: 8}},
+		}
+		if _, err := g.AddAnnotated(genome.name, a, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	archive := filepath.Join(dir, "backup.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := g.Backup(f); err != nil {
+		fmt.Println(err)
+		return
+	}
+	f.Close()
+
+	m, err := VerifyBackup(archive)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(m.Format, m.Backend, m.Config.K, m.Samples, m.Nodes, m.Edges)
+	for _, file := range m.Files {
+		fmt.Println(file.Name)
+	}
+
+	restored, err := openTestGraph(filepath.Join(dir, "b"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer restored.Close()
+	restored.K = 11
+	if _, err := restored.Restore(archive, context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	before, _ := g.LoadIndex()
+	after, _ := restored.LoadIndex()
+	fmt.Println(restored.K, reflect.DeepEqual(before, after))
+	uid, _ := restored.nextUID()
+	fmt.Println(uid)
+
+	_, err = restored.Restore(archive, context.Background())
+	fmt.Println(err)
+
+	// Swap a byte of the kv entry, keeping the manifest.
+	tampered := filepath.Join(dir, "tampered.tar.gz")
+	if err := rewriteBackup(archive, tampered, kvEntry); err != nil {
+		fmt.Println(err)
+		return
+	}
+	_, err = VerifyBackup(tampered)
+	fmt.Println(err)
+	// Output:
+	// prairiedog-backup embedded 5 [a b] 18 18
+	// nodes.tsv
+	// edges.tsv
+	// kv
+	// 5 true
+	// 19
+	// store is not empty
+	// kv: checksum mismatch
+}
+
+// rewriteBackup copies an archive, flipping the last byte of one entry.
+func rewriteBackup(src string, dst string, entry string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	gr, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gr)
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if hdr.Name == entry {
+			data[len(data)-1] ^= 1
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, buf.Bytes(), 0644)
+}
+
+func Example_remapRecord() {
+	uids := map[uint64]uint64{1: 0x10, 2: 0x20}
+	paths := map[string]bool{">a": true}
+	for _, kv := range [][2]string{
+		{"kmer/GATTA", "1"},
+		{"node/2", "ATTAC"},
+		{">a", "[1,2]"},
+		{"unitigof/1", "7"},
+		{"samples", `["a"]`},
+	} {
+		key, val, err := remapRecord(uids, paths, []byte(kv[0]), []byte(kv[1]))
+		fmt.Println(string(key), string(val), err)
+	}
+	_, _, err := remapRecord(uids, paths, []byte("kmer/CCCCC"), []byte("3"))
+	fmt.Println(err)
+	// Output:
+	// kmer/GATTA 16 <nil>
+	// node/32 ATTAC <nil>
+	// >a [16,32] <nil>
+	// unitigof/16 7 <nil>
+	// samples ["a"] <nil>
+	// uid 3 is not a node
+}
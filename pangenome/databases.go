@@ -2,19 +2,24 @@ package pangenome
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
-	"log"
+	"io/ioutil"
+	"net"
 	"os"
-	"path"
+	"strconv"
+	"strings"
 
 	"github.com/dgraph-io/badger"
 	"github.com/dgraph-io/dgo"
 	"github.com/dgraph-io/dgo/protos/api"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var Schema = `
-	Sequence: string @index(term) @upsert .
+	sequence: string @index(exact) @upsert .
 `
 
 type KmerNode struct {
@@ -24,51 +29,283 @@ type KmerNode struct {
 	ReverseNodes []KmerNode `json:"reverse,omitempty"`
 }
 
-func setupDgraph(address string, port string) (*dgo.Dgraph, error) {
+func setupDgraph() (*dgo.Dgraph, error) {
 	// Dial a gRPC connection. The address to dial to can be configured when
 	// setting up the dgraph cluster.
-	db := fmt.Sprintf("%s:%s", address, port)
-	d, err := grpc.Dial(db, grpc.WithInsecure())
-	if err != nil {
-		log.Fatal(err)
+	return dialDgraph(envOptions())
+}
+
+// envOptions are DefaultOptions with the backend, Badger directory and Dgraph
+// servers taken from PRAIRIEDOG_BACKEND, PRAIRIEDOG_BADGER_DIR and
+// PRAIRIEDOG_DGRAPH_ADDRESSES where set, as the prairiedog command reads them.
+func envOptions() Options {
+	opts := DefaultOptions
+	if os.Getenv("PRAIRIEDOG_BACKEND") == "embedded" {
+		opts.Embedded = true
+	}
+	if dir := os.Getenv("PRAIRIEDOG_BADGER_DIR"); dir != "" {
+		opts.Dir = dir
 	}
+	for _, addr := range strings.Split(os.Getenv("PRAIRIEDOG_DGRAPH_ADDRESSES"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			opts.DgraphAddrs = append(opts.DgraphAddrs, addr)
+		}
+	}
+	return opts
+}
 
-	dc := dgo.NewDgraphClient(
-		api.NewDgraphClient(d),
-	)
+// TLSOptions secure the connections to Dgraph. CACert verifies the servers,
+// in place of the system's roots, and Cert and Key are the client's own
+// certificate for servers requiring one.
+type TLSOptions struct {
+	Enabled    bool
+	CACert     string
+	Cert       string
+	Key        string
+	ServerName string
+}
 
-	setupSchema(dc)
+func (t TLSOptions) config() (*tls.Config, error) {
+	c := &tls.Config{ServerName: t.ServerName}
+	if t.CACert != "" {
+		pem, err := ioutil.ReadFile(t.CACert)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CACert)
+		}
+	}
+	if t.Cert != "" || t.Key != "" {
+		cert, err := tls.LoadX509KeyPair(t.Cert, t.Key)
+		if err != nil {
+			return nil, err
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
 
-	return dc, err
+// dialDgraph connects to every Dgraph server in opts and sets up the schema,
+// returning an error rather than exiting if it can't.
+func dialDgraph(opts Options) (*dgo.Dgraph, error) {
+	addrs := opts.DgraphAddrs
+	if len(addrs) == 0 {
+		addrs = []string{net.JoinHostPort(opts.DgraphHost, opts.DgraphPort)}
+	}
+	creds := grpc.WithInsecure()
+	if opts.TLS.Enabled {
+		c, err := opts.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		creds = grpc.WithTransportCredentials(credentials.NewTLS(c))
+	}
+	clients := make([]api.DgraphClient, 0, len(addrs))
+	for _, addr := range addrs {
+		conn, err := grpc.Dial(addr, creds)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, api.NewDgraphClient(conn))
+	}
+	dc := dgo.NewDgraphClient(clients...)
+	if err := setupSchema(dc); err != nil {
+		return nil, err
+	}
+	return dc, nil
 }
 
-func setupSchema(c *dgo.Dgraph) {
-	err := c.Alter(context.Background(), &api.Operation{
+func setupSchema(c *dgo.Dgraph) error {
+	return c.Alter(context.Background(), &api.Operation{
 		Schema: Schema,
 	})
-	if err != nil {
-		log.Fatal(err)
-	}
 }
 
-func setupBadger() *badger.DB {
-	// Open the Badger database located in the user's home directory.
+func setupBadger() (*badger.DB, error) {
+	// Open the Badger database in the configured directory.
 	// It will be created if it doesn't exist.
 	opts := badger.DefaultOptions
+	dir := envOptions().Dir
+	opts.Dir = dir
+	opts.ValueDir = dir
+	return badger.Open(opts)
+}
+
+// batch groups Badger writes into as few transactions as possible, committing
+// and starting a new one whenever a transaction grows too big or holds limit
+// writes.
+type batch struct {
+	bd    *badger.DB
+	txn   *badger.Txn
+	limit int
+	n     int
+}
+
+func (g *Graph) newBatch() *batch {
+	return &batch{
+		bd:    g.bd,
+		limit: g.BatchSize,
+	}
+}
 
-	// Get currenty working directory.
-	wdir, err := os.Getwd()
+func (b *batch) set(key []byte, value []byte) error {
+	return b.write(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+func (b *batch) delete(key []byte) error {
+	return b.write(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+func (b *batch) write(fn func(txn *badger.Txn) error) error {
+	if b.txn == nil {
+		b.txn = b.bd.NewTransaction(true)
+	}
+	err := fn(b.txn)
+	if err == badger.ErrTxnTooBig {
+		if err := b.flush(); err != nil {
+			return err
+		}
+		b.txn = b.bd.NewTransaction(true)
+		err = fn(b.txn)
+	}
 	if err != nil {
-		log.Fatal(err)
+		b.txn.Discard()
+		b.txn = nil
+		return err
+	}
+	b.n++
+	if b.limit > 0 && b.n >= b.limit {
+		return b.flush()
 	}
+	return nil
+}
 
-	dir := path.Join(wdir, "badger")
+// flush commits any pending writes.
+func (b *batch) flush() error {
+	if b.txn == nil {
+		return nil
+	}
+	err := b.txn.Commit(nil)
+	b.txn = nil
+	b.n = 0
+	return err
+}
 
-	opts.Dir = dir
-	opts.ValueDir = dir
-	db, err := badger.Open(opts)
+// dropPrefix deletes every key in Badger starting with prefix.
+func (g *Graph) dropPrefix(prefix string) error {
+	var keys [][]byte
+	err := g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		p := []byte(prefix)
+		for it.Seek(p); it.ValidForPrefix(p); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b := g.newBatch()
+	for _, key := range keys {
+		if err := b.delete(key); err != nil {
+			return err
+		}
+	}
+	return b.flush()
+}
+
+// empty returns true if Badger holds no keys besides the schema version and
+// k.
+func (g *Graph) empty() (bool, error) {
+	empty := true
+	err := g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if key := string(it.Item().Key()); key != schemaKey && key != kKey {
+				empty = false
+				break
+			}
+		}
+		return nil
+	})
+	return empty, err
+}
+
+// storedK returns the length of the k-mers in Badger, and whether it has been
+// recorded. Stores from before k was recorded have it taken from any k-mer;
+// a store without k-mers returns 0.
+func (g *Graph) storedK() (int, bool, error) {
+	k, err := g.GetKVInt(kKey)
+	if err == nil {
+		return k, true, nil
+	}
+	if err != badger.ErrKeyNotFound {
+		return 0, false, err
+	}
+	k = 0
+	err = g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix := []byte(kmerPrefix)
+		it.Seek(prefix)
+		if it.ValidForPrefix(prefix) {
+			k = len(it.Item().Key()) - len(prefix)
+		}
+		return nil
+	})
+	return k, false, err
+}
+
+// recordK stores g.K in Badger on the first write, so later opens can't mix
+// k-mer lengths.
+func (g *Graph) recordK() error {
+	if g.kRecorded {
+		return nil
+	}
+	if _, err := g.SetKVInt(kKey, g.K); err != nil {
+		return err
+	}
+	g.kRecorded = true
+	return nil
+}
+
+// nextUID hands out node uids for an embedded graph from a counter kept in
+// Badger, starting at 1 as Dgraph never assigns 0.
+func (g *Graph) nextUID() (uint64, error) {
+	uid := uint64(1)
+	err := g.bd.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(nextUIDKey))
+		switch err {
+		case nil:
+			err = item.Value(func(val []byte) error {
+				uid, err = strconv.ParseUint(string(val), 10, 64)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		case badger.ErrKeyNotFound:
+		default:
+			return err
+		}
+		return txn.Set([]byte(nextUIDKey), []byte(strconv.FormatUint(uid+1, 10)))
+	})
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
-	return db
+	return uid, nil
 }
@@ -5,7 +5,11 @@ import (
 )
 
 func ExampleBadger() {
-	bd := setupBadger()
+	bd, err := setupBadger()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	defer bd.Close()
 	s := bd.Tables()
 	fmt.Println(s)
@@ -14,7 +18,7 @@ func ExampleBadger() {
 }
 
 func ExampleDgraph() {
-	_, err := setupDgraph("localhost", "9080")
+	_, err := setupDgraph()
 	fmt.Println(err)
 	// Output:
 	// <nil>
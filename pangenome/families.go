@@ -0,0 +1,252 @@
+package pangenome
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/superphy/prairiedog/kmers"
+)
+
+// FamilyOptions control how annotated genes are clustered into families.
+type FamilyOptions struct {
+	Type       string  // feature type to cluster, e.g. CDS.
+	MinJaccard float64 // smallest share of k-mers for two genes to be joined.
+}
+
+// DefaultFamilyOptions cluster CDS features, as Roary does with Prokka output.
+var DefaultFamilyOptions = FamilyOptions{
+	Type:       "CDS",
+	MinJaccard: 0.8,
+}
+
+// Family is a group of genes sharing k-mers. Name is the most common gene name
+// in the family, or group_<n> if it's unnamed or the name is taken by a larger
+// family, in which case the name is kept as NonUnique.
+type Family struct {
+	Name      string
+	NonUnique string
+	Product   string
+	Genes     []*Feature
+}
+
+// Samples returns the distinct samples with a gene in the family.
+func (f *Family) Samples() []string {
+	var samples []string
+	seen := make(map[string]bool)
+	for _, g := range f.Genes {
+		if !seen[g.Sample] {
+			seen[g.Sample] = true
+			samples = append(samples, g.Sample)
+		}
+	}
+	return samples
+}
+
+// Families clusters the annotated genes of the given type by single linkage,
+// joining two genes when the Jaccard index of their k-mers is at least
+// MinJaccard. K-mers are compared in canonical form, the lesser of a k-mer and
+// its reverse complement, so genes annotated on opposite strands still match.
+// Families are ordered by the number of samples containing them, then by name.
+func (idx *Index) Families(opts FamilyOptions) []*Family {
+	var genes []*Feature
+	for _, f := range idx.Features {
+		if f.Type == opts.Type && len(f.Nodes) > 0 {
+			genes = append(genes, f)
+		}
+	}
+
+	canonical := make(map[uint64]string)
+	sets := make([]map[string]bool, len(genes))
+	byKmer := make(map[string][]int)
+	for i, g := range genes {
+		sets[i] = make(map[string]bool, len(g.Nodes))
+		for _, uid := range g.Nodes {
+			kmer, ok := canonical[uid]
+			if !ok {
+				kmer = idx.Sequences[uid]
+				if rc := kmers.ReverseComplement(kmer); rc < kmer {
+					kmer = rc
+				}
+				canonical[uid] = kmer
+			}
+			if !sets[i][kmer] {
+				sets[i][kmer] = true
+				byKmer[kmer] = append(byKmer[kmer], i)
+			}
+		}
+	}
+
+	parent := make([]int, len(genes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+	for i := range genes {
+		shared := make(map[int]int)
+		for kmer := range sets[i] {
+			for _, j := range byKmer[kmer] {
+				if j > i {
+					shared[j]++
+				}
+			}
+		}
+		for j, n := range shared {
+			jaccard := float64(n) / float64(len(sets[i])+len(sets[j])-n)
+			if jaccard < opts.MinJaccard {
+				continue
+			}
+			if a, b := find(i), find(j); a != b {
+				parent[a] = b
+			}
+		}
+	}
+
+	groups := make(map[int]*Family)
+	var families []*Family
+	for i, g := range genes {
+		root := find(i)
+		f, ok := groups[root]
+		if !ok {
+			f = &Family{}
+			groups[root] = f
+			families = append(families, f)
+		}
+		f.Genes = append(f.Genes, g)
+	}
+	for _, f := range families {
+		f.Name = mostCommon(f.Genes, func(g *Feature) string { return g.Name })
+		f.Product = mostCommon(f.Genes, func(g *Feature) string { return g.Product })
+	}
+
+	sort.SliceStable(families, func(i, j int) bool {
+		si, sj := len(families[i].Samples()), len(families[j].Samples())
+		if si != sj {
+			return si > sj
+		}
+		return families[i].Name < families[j].Name
+	})
+	used := make(map[string]bool)
+	for i, f := range families {
+		if f.Name == "" || used[f.Name] {
+			f.NonUnique = f.Name
+			f.Name = fmt.Sprintf("group_%v", i+1)
+		}
+		used[f.Name] = true
+	}
+	return families
+}
+
+// mostCommon returns the most common non-empty value among genes, breaking
+// ties alphabetically.
+func mostCommon(genes []*Feature, value func(*Feature) string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, g := range genes {
+		v := value(g)
+		if v == "" {
+			continue
+		}
+		counts[v]++
+		if counts[v] > counts[best] || (counts[v] == counts[best] && v < best) {
+			best = v
+		}
+	}
+	return best
+}
+
+// WriteGenePresenceAbsence writes families in the layout of Roary's
+// gene_presence_absence.csv, with one column per sample listing the ids of
+// its genes in each family. Roary's fragment and QC columns are left empty.
+func WriteGenePresenceAbsence(w io.Writer, families []*Family, samples []string) error {
+	header := []string{
+		"Gene", "Non-unique Gene name", "Annotation", "No. isolates",
+		"No. sequences", "Avg sequences per isolate", "Genome Fragment",
+		"Order within Fragment", "Accessory Fragment",
+		"Accessory Order with Fragment", "QC", "Min group size nuc",
+		"Max group size nuc", "Avg group size nuc",
+	}
+	if err := writeQuoted(w, append(header, samples...)); err != nil {
+		return err
+	}
+	for _, f := range families {
+		ids := make(map[string][]string)
+		min, max, total := 0, 0, 0
+		for i, g := range f.Genes {
+			id := g.ID
+			if id == "" {
+				id = g.Label()
+			}
+			ids[g.Sample] = append(ids[g.Sample], id)
+			size := g.End - g.Start
+			if i == 0 || size < min {
+				min = size
+			}
+			if size > max {
+				max = size
+			}
+			total += size
+		}
+		isolates := len(f.Samples())
+		row := []string{
+			f.Name, f.NonUnique, f.Product,
+			fmt.Sprint(isolates),
+			fmt.Sprint(len(f.Genes)),
+			fmt.Sprintf("%.4g", float64(len(f.Genes))/float64(isolates)),
+			"", "", "", "", "",
+			fmt.Sprint(min), fmt.Sprint(max),
+			fmt.Sprintf("%.4g", float64(total)/float64(len(f.Genes))),
+		}
+		for _, s := range samples {
+			// Roary separates paralogs within a cell by tabs.
+			row = append(row, strings.Join(ids[s], "\t"))
+		}
+		if err := writeQuoted(w, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteRtab writes families as Roary's gene_presence_absence.Rtab, a tab
+// separated 0/1 matrix of families by samples.
+func WriteRtab(w io.Writer, families []*Family, samples []string) error {
+	if _, err := fmt.Fprintf(w, "Gene\t%s\n", strings.Join(samples, "\t")); err != nil {
+		return err
+	}
+	for _, f := range families {
+		present := make(map[string]bool)
+		for _, s := range f.Samples() {
+			present[s] = true
+		}
+		row := make([]string, len(samples))
+		for i, s := range samples {
+			row[i] = "0"
+			if present[s] {
+				row[i] = "1"
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", f.Name, strings.Join(row, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeQuoted writes a CSV row with every field quoted, as Roary does.
+func writeQuoted(w io.Writer, fields []string) error {
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = `"` + strings.Replace(field, `"`, `""`, -1) + `"`
+	}
+	_, err := fmt.Fprintln(w, strings.Join(quoted, ","))
+	return err
+}
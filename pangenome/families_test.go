@@ -0,0 +1,56 @@
+package pangenome
+
+import (
+	"fmt"
+	"os"
+)
+
+func ExampleWriteGenePresenceAbsence() {
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGGTTTAAACC"})
+	idx.AddGenome("b", []string{">b"}, []string{"GATTACAGCGTCCATGGATTTGGGC"})
+	idx.AddGenome("c", []string{">c"}, []string{"CCCCCCGTTTAAACC"})
+	idx.Annotate("a", []*Feature{
+		{Contig: ">a", Type: "CDS", ID: "a_1", Name: "stx2", Product: "toxin", Start: 0, End: 17},
+		{Contig: ">a", Type: "CDS", ID: "a_2", Start: 15, End: 25},
+	})
+	idx.Annotate("b", []*Feature{
+		{Contig: ">b", Type: "CDS", ID: "b_1", Name: "stx2", Start: 0, End: 16},
+		{Contig: ">b", Type: "gene", ID: "b_2", Name: "stx2", Start: 0, End: 16},
+	})
+	idx.Annotate("c", []*Feature{
+		{Contig: ">c", Type: "CDS", ID: "c_1", Name: "stx2", Start: 5, End: 15},
+	})
+
+	families := idx.Families(DefaultFamilyOptions)
+	WriteGenePresenceAbsence(os.Stdout, families, idx.Samples)
+	WriteRtab(os.Stdout, families, idx.Samples)
+	// Output:
+	// "Gene","Non-unique Gene name","Annotation","No. isolates","No. sequences","Avg sequences per isolate","Genome Fragment","Order within Fragment","Accessory Fragment","Accessory Order with Fragment","QC","Min group size nuc","Max group size nuc","Avg group size nuc","a","b","c"
+	// "stx2","","toxin","2","2","1","","","","","","16","17","16.5","a_1","b_1",""
+	// "group_2","","","1","1","1","","","","","","10","10","10","a_2","",""
+	// "group_3","stx2","","1","1","1","","","","","","10","10","10","","","c_1"
+	// Gene	a	b	c
+	// stx2	1	1	0
+	// group_2	1	0	0
+	// group_3	0	0	1
+}
+
+func ExampleIndex_Families() {
+	// b carries a's gene on the opposite strand, so they share no nodes.
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGGAAAA"})
+	idx.AddGenome("b", []string{">b"}, []string{"TTTTCCATGGACGCTGTAATC"})
+	idx.Annotate("a", []*Feature{
+		{Contig: ">a", Type: "CDS", ID: "a_1", Name: "stx2", Strand: "+", Start: 0, End: 17},
+	})
+	idx.Annotate("b", []*Feature{
+		{Contig: ">b", Type: "CDS", ID: "b_1", Name: "stx2", Strand: "-", Start: 4, End: 21},
+	})
+
+	for _, f := range idx.Families(DefaultFamilyOptions) {
+		fmt.Println(f.Name, f.Samples())
+	}
+	// Output:
+	// stx2 [a b]
+}
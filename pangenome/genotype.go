@@ -0,0 +1,167 @@
+package pangenome
+
+import (
+	"sort"
+)
+
+// Genotype compares a genome against the pangenome without adding it.
+type Genotype struct {
+	Sample     string
+	Kmers      int // distinct k-mers in the genome.
+	Novel      int // of which are not in the graph.
+	Edges      int // distinct edges between consecutive k-mers.
+	NovelEdges int // of which are not in the graph.
+	Components []*Component
+	Nearest    []Similarity
+}
+
+// Component is a connected set of accessory unitigs, such as a gene island or
+// plasmid. It's present in a genome with at least half of its k-mers.
+type Component struct {
+	Unitigs []uint64
+	Kmers   int
+	Found   int
+	Present bool
+}
+
+// Similarity is the Jaccard index between the k-mers of a genome and those of
+// an existing sample.
+type Similarity struct {
+	Sample  string
+	Shared  int
+	Jaccard float64
+}
+
+// Genotype compares a genome's k-mers and edges to the index, reporting novel
+// k-mers, which accessory components (unitigs outside the core partition,
+// joined by edges) are present or absent, and every sample ordered by shared
+// k-mers. The index isn't changed.
+func (idx *Index) Genotype(name string, sequences []string, unitigs []*Unitig, t Thresholds) *Genotype {
+	gt := &Genotype{
+		Sample: name,
+	}
+
+	// The genome's k-mers already in the graph, by uid.
+	known := make(map[uint64]bool)
+	novel := make(map[string]bool)
+	edges := make(map[[2]string]bool)
+	for _, seq := range sequences {
+		var prev uint64
+		prevKnown := false
+		for j := 0; j+idx.K <= len(seq); j++ {
+			kmer := seq[j : j+idx.K]
+			uid, ok := idx.Lookup(kmer)
+			if ok {
+				known[uid] = true
+			} else {
+				novel[kmer] = true
+			}
+			if j > 0 {
+				edge := [2]string{seq[j-1 : j-1+idx.K], kmer}
+				if !edges[edge] {
+					edges[edge] = true
+					if !ok || !prevKnown || idx.Forward[prev][uid] == 0 {
+						gt.NovelEdges++
+					}
+				}
+			}
+			prev, prevKnown = uid, ok
+		}
+	}
+	gt.Kmers = len(known) + len(novel)
+	gt.Novel = len(novel)
+	gt.Edges = len(edges)
+
+	parts := idx.Partition(unitigs, t)
+	var accessory []*Unitig
+	for _, p := range Partitions {
+		if p != Core {
+			accessory = append(accessory, parts[p]...)
+		}
+	}
+	for _, members := range unitigComponents(idx, accessory) {
+		c := &Component{}
+		for _, u := range members {
+			c.Unitigs = append(c.Unitigs, u.ID)
+			for _, uid := range u.Members {
+				c.Kmers++
+				if known[uid] {
+					c.Found++
+				}
+			}
+		}
+		c.Present = 2*c.Found >= c.Kmers
+		gt.Components = append(gt.Components, c)
+	}
+
+	shared := make([]int, len(idx.Samples))
+	for uid := range known {
+		for _, i := range idx.Colours[uid].Indices() {
+			shared[i]++
+		}
+	}
+	sizes := make([]int, len(idx.Samples))
+	for _, c := range idx.Colours {
+		for _, i := range c.Indices() {
+			sizes[i]++
+		}
+	}
+	for i, sample := range idx.Samples {
+		s := Similarity{
+			Sample: sample,
+			Shared: shared[i],
+		}
+		if union := gt.Kmers + sizes[i] - shared[i]; union > 0 {
+			s.Jaccard = float64(shared[i]) / float64(union)
+		}
+		gt.Nearest = append(gt.Nearest, s)
+	}
+	sort.SliceStable(gt.Nearest, func(a, b int) bool {
+		return gt.Nearest[a].Jaccard > gt.Nearest[b].Jaccard
+	})
+	return gt
+}
+
+// unitigComponents groups unitigs joined by an edge from the last k-mer of one
+// to the first k-mer of another, in either direction. Components are ordered
+// by their lowest unitig id.
+func unitigComponents(idx *Index, unitigs []*Unitig) [][]*Unitig {
+	byFirst := make(map[uint64]int, len(unitigs))
+	for i, u := range unitigs {
+		byFirst[u.Members[0]] = i
+	}
+	parent := make([]int, len(unitigs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(x int) int {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+	for i, u := range unitigs {
+		for dst := range idx.Forward[u.Members[len(u.Members)-1]] {
+			if j, ok := byFirst[dst]; ok {
+				if a, b := find(i), find(j); a != b {
+					parent[a] = b
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]*Unitig)
+	for i, u := range unitigs {
+		root := find(i)
+		groups[root] = append(groups[root], u)
+	}
+	components := make([][]*Unitig, 0, len(groups))
+	for _, members := range groups {
+		sort.Slice(members, func(a, b int) bool { return members[a].ID < members[b].ID })
+		components = append(components, members)
+	}
+	sort.Slice(components, func(a, b int) bool { return components[a][0].ID < components[b][0].ID })
+	return components
+}
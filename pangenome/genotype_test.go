@@ -0,0 +1,32 @@
+package pangenome
+
+import (
+	"fmt"
+)
+
+func ExampleIndex_Genotype() {
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	idx.AddGenome("b", []string{">b"}, []string{"GATTACAGCGTCCATGGTTTAAACC"})
+	idx.AddGenome("c", []string{">c"}, []string{"GATTACAGCTTCCATGG"})
+
+	unitigs := idx.Unitigs(idx.Nodes())
+	for i, u := range unitigs {
+		u.ID = uint64(i)
+	}
+	gt := idx.Genotype("d", []string{"GATTACAGCGTCCATGGTTTAAAGG"}, unitigs, DefaultThresholds)
+	fmt.Println(gt.Novel, gt.Kmers, gt.NovelEdges, gt.Edges)
+	for _, c := range gt.Components {
+		fmt.Println(c.Unitigs, c.Found, c.Kmers, c.Present)
+	}
+	for _, s := range gt.Nearest {
+		fmt.Printf("%s %v %.2f\n", s.Sample, s.Shared, s.Jaccard)
+	}
+	// Output:
+	// 2 21 2 20
+	// [1] 5 5 true
+	// [3] 0 5 false
+	// b 19 0.83
+	// a 13 0.62
+	// c 8 0.31
+}
@@ -0,0 +1,416 @@
+package pangenome
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/superphy/prairiedog/kmers"
+)
+
+// GFA holds the segments, links and paths read from a GFA 1.x file, as written
+// by tools such as minigraph or pggb.
+type GFA struct {
+	Segments map[string]*GFASegment
+	Links    []GFALink
+	Paths    []GFAPath
+	order    []string // segment names in file order.
+}
+
+// GFASegment is an S line.
+type GFASegment struct {
+	Name     string
+	Sequence string
+	Tags     map[string]string // optional fields keyed by tag name, e.g. SN.
+}
+
+// GFALink is an L line.
+type GFALink struct {
+	From        string
+	FromReverse bool
+	To          string
+	ToReverse   bool
+	Overlap     string
+}
+
+// GFAStep is a single oriented segment visited by a path.
+type GFAStep struct {
+	Segment string
+	Reverse bool
+}
+
+// GFAPath is a P or W line. Sample is the name the path is loaded under.
+type GFAPath struct {
+	Name     string
+	Sample   string
+	Steps    []GFAStep
+	Overlaps []string
+}
+
+// ReadGFA parses a GFA 1.x stream. Segments, links, paths (P) and walks (W) are
+// kept; all other record types are ignored. If the file has no paths, as with
+// the rGFA output of minigraph, paths are rebuilt from the stable sequence
+// (SN/SO) tags of the segments.
+func ReadGFA(r io.Reader) (*GFA, error) {
+	gfa := &GFA{
+		Segments: make(map[string]*GFASegment),
+	}
+
+	scanner := bufio.NewScanner(r)
+	// Segments can be much longer than the default 64KB token limit.
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		var err error
+		switch fields[0] {
+		case "S":
+			err = gfa.parseSegment(fields)
+		case "L":
+			err = gfa.parseLink(fields)
+		case "P":
+			err = gfa.parsePath(fields)
+		case "W":
+			err = gfa.parseWalk(fields)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gfa line %v: %v", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(gfa.Paths) == 0 {
+		gfa.stablePaths()
+	}
+
+	return gfa, nil
+}
+
+func (gfa *GFA) parseSegment(fields []string) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("segment has %v fields, expected at least 3", len(fields))
+	}
+	if fields[2] == "*" {
+		return fmt.Errorf("segment %s has no sequence", fields[1])
+	}
+	s := &GFASegment{
+		Name:     fields[1],
+		Sequence: strings.ToUpper(fields[2]),
+		Tags:     make(map[string]string),
+	}
+	for _, tag := range fields[3:] {
+		// Tags are of the form TAG:TYPE:VALUE.
+		parts := strings.SplitN(tag, ":", 3)
+		if len(parts) == 3 {
+			s.Tags[parts[0]] = parts[2]
+		}
+	}
+	gfa.Segments[s.Name] = s
+	gfa.order = append(gfa.order, s.Name)
+	return nil
+}
+
+func (gfa *GFA) parseLink(fields []string) error {
+	if len(fields) < 6 {
+		return fmt.Errorf("link has %v fields, expected at least 6", len(fields))
+	}
+	gfa.Links = append(gfa.Links, GFALink{
+		From:        fields[1],
+		FromReverse: fields[2] == "-",
+		To:          fields[3],
+		ToReverse:   fields[4] == "-",
+		Overlap:     fields[5],
+	})
+	return nil
+}
+
+func (gfa *GFA) parsePath(fields []string) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("path has %v fields, expected at least 3", len(fields))
+	}
+	p := GFAPath{
+		Name:   fields[1],
+		Sample: panSNSample(fields[1]),
+	}
+	for _, step := range strings.Split(fields[2], ",") {
+		if len(step) < 2 {
+			return fmt.Errorf("path %s has an invalid step %q", p.Name, step)
+		}
+		p.Steps = append(p.Steps, GFAStep{
+			Segment: step[:len(step)-1],
+			Reverse: step[len(step)-1] == '-',
+		})
+	}
+	if len(fields) > 3 && fields[3] != "*" {
+		p.Overlaps = strings.Split(fields[3], ",")
+	}
+	gfa.Paths = append(gfa.Paths, p)
+	return nil
+}
+
+func (gfa *GFA) parseWalk(fields []string) error {
+	if len(fields) < 7 {
+		return fmt.Errorf("walk has %v fields, expected at least 7", len(fields))
+	}
+	sample := fields[1]
+	if fields[2] != "0" {
+		sample = fmt.Sprintf("%s#%s", fields[1], fields[2])
+	}
+	p := GFAPath{
+		Name:   fmt.Sprintf("%s#%s", sample, fields[3]),
+		Sample: sample,
+	}
+	walk := fields[6]
+	for i := 0; i < len(walk); {
+		if walk[i] != '>' && walk[i] != '<' {
+			return fmt.Errorf("walk %s has an invalid orientation %q", p.Name, walk[i])
+		}
+		j := i + 1
+		for j < len(walk) && walk[j] != '>' && walk[j] != '<' {
+			j++
+		}
+		p.Steps = append(p.Steps, GFAStep{
+			Segment: walk[i+1 : j],
+			Reverse: walk[i] == '<',
+		})
+		i = j
+	}
+	gfa.Paths = append(gfa.Paths, p)
+	return nil
+}
+
+// stablePaths rebuilds paths from the SN/SO tags of an rGFA. Segments of the
+// same stable sequence are sorted by offset; a gap in the offsets starts a new
+// path.
+func (gfa *GFA) stablePaths() {
+	byName := make(map[string][]*GFASegment)
+	var names []string
+	for _, name := range gfa.order {
+		s := gfa.Segments[name]
+		sn, ok := s.Tags["SN"]
+		if !ok {
+			continue
+		}
+		if _, ok := s.Tags["SO"]; !ok {
+			continue
+		}
+		if _, seen := byName[sn]; !seen {
+			names = append(names, sn)
+		}
+		byName[sn] = append(byName[sn], s)
+	}
+
+	for _, sn := range names {
+		segs := byName[sn]
+		sort.Slice(segs, func(i, j int) bool {
+			return segmentOffset(segs[i]) < segmentOffset(segs[j])
+		})
+		var p GFAPath
+		end := -1
+		for _, s := range segs {
+			so := segmentOffset(s)
+			if end != -1 && so != end {
+				gfa.Paths = append(gfa.Paths, p)
+				p = GFAPath{}
+			}
+			if len(p.Steps) == 0 {
+				p.Name = fmt.Sprintf("%s:%v", sn, so)
+				p.Sample = panSNSample(sn)
+			}
+			p.Steps = append(p.Steps, GFAStep{Segment: s.Name})
+			end = so + len(s.Sequence)
+		}
+		if len(p.Steps) != 0 {
+			gfa.Paths = append(gfa.Paths, p)
+		}
+	}
+}
+
+func segmentOffset(s *GFASegment) int {
+	so, err := strconv.Atoi(s.Tags["SO"])
+	if err != nil {
+		return 0
+	}
+	return so
+}
+
+// panSNSample returns the sample part of a PanSN path name
+// (sample#haplotype#contig), which is everything before the last '#'. Names
+// without a '#' are their own sample.
+func panSNSample(name string) string {
+	i := strings.LastIndex(name, "#")
+	if i <= 0 {
+		return name
+	}
+	return name[:i]
+}
+
+// Spell returns the sequence of a path, trimming the overlap between
+// consecutive segments as given by the path itself or by the matching link.
+func (gfa *GFA) Spell(p GFAPath) (string, error) {
+	var sb strings.Builder
+	for i, step := range p.Steps {
+		s, ok := gfa.Segments[step.Segment]
+		if !ok {
+			return "", fmt.Errorf("path %s refers to unknown segment %s", p.Name, step.Segment)
+		}
+		seq := s.Sequence
+		if step.Reverse {
+			seq = kmers.ReverseComplement(seq)
+		}
+		if i > 0 {
+			trim, err := overlapLength(gfa.overlap(p, i))
+			if err != nil {
+				return "", fmt.Errorf("path %s: %v", p.Name, err)
+			}
+			if trim > len(seq) {
+				return "", fmt.Errorf("path %s: overlap of %v is longer than segment %s", p.Name, trim, s.Name)
+			}
+			seq = seq[trim:]
+		}
+		sb.WriteString(seq)
+	}
+	return sb.String(), nil
+}
+
+// overlap returns the CIGAR overlap between step i-1 and step i of p.
+func (gfa *GFA) overlap(p GFAPath, i int) string {
+	if i-1 < len(p.Overlaps) {
+		return p.Overlaps[i-1]
+	}
+	prev, cur := p.Steps[i-1], p.Steps[i]
+	for _, l := range gfa.Links {
+		if l.From == prev.Segment && l.FromReverse == prev.Reverse &&
+			l.To == cur.Segment && l.ToReverse == cur.Reverse {
+			return l.Overlap
+		}
+		// The same link read from the opposite strand.
+		if l.From == cur.Segment && l.FromReverse != cur.Reverse &&
+			l.To == prev.Segment && l.ToReverse != prev.Reverse {
+			return l.Overlap
+		}
+	}
+	return "*"
+}
+
+// overlapLength returns how many bases of the second segment are covered by a
+// CIGAR overlap.
+func overlapLength(cigar string) (int, error) {
+	if cigar == "*" || cigar == "" {
+		return 0, nil
+	}
+	total, n := 0, 0
+	for _, c := range cigar {
+		switch {
+		case c >= '0' && c <= '9':
+			n = n*10 + int(c-'0')
+		case strings.ContainsRune("MI=XS", c):
+			total += n
+			n = 0
+		case strings.ContainsRune("DNHP", c):
+			n = 0
+		default:
+			return 0, fmt.Errorf("invalid overlap %q", cigar)
+		}
+	}
+	return total, nil
+}
+
+// ImportGFA decomposes every path of a GFA into k-mers of length g.K and adds
+// them to the graph, one sample per distinct GFA sample name. K-mers already in
+// the graph are reused, so a GFA can either seed an empty store or be merged
+// into an existing pangenome. It returns the names of the samples added.
+func (g *Graph) ImportGFA(gfa *GFA, contextMain context.Context) ([]string, error) {
+	ctx, cancel := context.WithCancel(contextMain)
+	defer cancel()
+
+	var samples []string
+	headers := make(map[string][]string)
+	sequences := make(map[string][]string)
+	for _, p := range gfa.Paths {
+		seq, err := gfa.Spell(p)
+		if err != nil {
+			return samples, err
+		}
+		if len(seq) < g.K {
+			log.Printf("WARNING: path %s is shorter than the chosen k-value of %v. Skipping path.", p.Name, g.K)
+			continue
+		}
+		if _, seen := headers[p.Sample]; !seen {
+			samples = append(samples, p.Sample)
+		}
+		headers[p.Sample] = append(headers[p.Sample], ">"+p.Name)
+		sequences[p.Sample] = append(sequences[p.Sample], seq)
+	}
+
+	var added []string
+	for _, sample := range samples {
+		km := kmers.NewFromSequences(headers[sample], sequences[sample])
+		km.K = g.K
+		if _, err := g.AddGenome(sample, km, ctx); err != nil {
+			return added, err
+		}
+		added = append(added, sample)
+	}
+	return added, nil
+}
+
+// WriteGFA writes unitigs as GFA 1.0 segments, linked wherever the last k-mer
+// of one unitig has an edge to the first k-mer of another. Links to unitigs
+// outside of the given set are dropped, so a subset is written as a subgraph.
+// Segments are tagged with their length (LN), the number of samples containing
+// them (SC) and their total edge weight (RC), and with the labels of any
+// annotated features they overlap (gn).
+func WriteGFA(w io.Writer, idx *Index, unitigs []*Unitig) error {
+	if _, err := fmt.Fprintf(w, "H\tVN:Z:1.0\n"); err != nil {
+		return err
+	}
+	first := make(map[uint64]*Unitig, len(unitigs))
+	for _, u := range unitigs {
+		first[u.Members[0]] = u
+		rc := 0
+		for _, weight := range u.Weights {
+			rc += weight
+		}
+		var gn string
+		if labels := idx.Labels(u.Members); len(labels) > 0 {
+			gn = "\tgn:Z:" + strings.Join(labels, ",")
+		}
+		_, err := fmt.Fprintf(w, "S\t%v\t%s\tLN:i:%v\tSC:i:%v\tRC:i:%v%s\n", u.ID, u.Sequence, len(u.Sequence), u.Colours.Count(), rc, gn)
+		if err != nil {
+			return err
+		}
+	}
+	for _, u := range unitigs {
+		last := u.Members[len(u.Members)-1]
+		dsts := make([]uint64, 0, len(idx.Forward[last]))
+		for dst := range idx.Forward[last] {
+			dsts = append(dsts, dst)
+		}
+		sort.Slice(dsts, func(i, j int) bool { return dsts[i] < dsts[j] })
+		for _, dst := range dsts {
+			v, ok := first[dst]
+			if !ok {
+				continue
+			}
+			_, err := fmt.Fprintf(w, "L\t%v\t+\t%v\t+\t%vM\tRC:i:%v\n", u.ID, v.ID, idx.K-1, idx.Forward[last][dst])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
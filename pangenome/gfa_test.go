@@ -0,0 +1,98 @@
+package pangenome
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func ExampleReadGFA() {
+	r := strings.NewReader(strings.Join([]string{
+		"H\tVN:Z:1.0",
+		"S\ts1\tACGTACGT",
+		"S\ts2\tGTTTCC",
+		"S\ts3\tAAACG",
+		"L\ts1\t+\ts2\t+\t2M",
+		"L\ts2\t+\ts3\t-\t0M",
+		"P\tA#1#chr\ts1+,s2+,s3-\t*",
+		"W\tB\t0\tchr\t0\t11\t>s3<s1",
+	}, "\n"))
+	gfa, _ := ReadGFA(r)
+	for _, p := range gfa.Paths {
+		seq, err := gfa.Spell(p)
+		fmt.Println(p.Sample, p.Name, seq, err)
+	}
+	// Output:
+	// A#1 A#1#chr ACGTACGTTTCCCGTTT <nil>
+	// B B#chr AAACGACGTACGT <nil>
+}
+
+func ExampleReadGFA_stable() {
+	r := strings.NewReader(strings.Join([]string{
+		"S\ts2\tGGG\tSN:Z:ref\tSO:i:4\tSR:i:0",
+		"S\ts1\tACGT\tSN:Z:ref\tSO:i:0\tSR:i:0",
+		"S\ts3\tTT\tSN:Z:ref\tSO:i:20\tSR:i:0",
+	}, "\n"))
+	gfa, _ := ReadGFA(r)
+	for _, p := range gfa.Paths {
+		seq, _ := gfa.Spell(p)
+		fmt.Println(p.Sample, p.Name, seq)
+	}
+	// Output:
+	// ref ref:0 ACGTGGG
+	// ref ref:20 TT
+}
+
+func ExampleGraph_ImportGFA() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+
+	r := strings.NewReader(strings.Join([]string{
+		"H\tVN:Z:1.0",
+		"S\ts1\tACGTACGT",
+		"S\ts2\tGTTTCC",
+		"S\ts3\tAAACG",
+		"S\ts4\tTTG",
+		"L\ts1\t+\ts2\t+\t2M",
+		"L\ts2\t+\ts3\t-\t0M",
+		"P\tA#1#chr\ts1+,s2+,s3-\t*",
+		"P\tA#1#short\ts4+\t*",
+		"W\tB\t0\tchr\t0\t11\t>s3<s1",
+	}, "\n"))
+	gfa, err := ReadGFA(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	added, err := g.ImportGFA(gfa, context.Background())
+	fmt.Println(added, err)
+
+	idx, err := g.LoadIndex()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i, sample := range idx.Samples {
+		headers, _ := g.SampleContigs(sample)
+		for _, header := range headers {
+			fmt.Println(sample, header, idx.Spell(idx.Path(i, header)))
+		}
+	}
+	// Output:
+	// [A#1 B] <nil>
+	// A#1 >A#1#chr ACGTACGTTTCCCGTTT
+	// B >B#chr AAACGACGTACGT
+}
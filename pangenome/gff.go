@@ -0,0 +1,288 @@
+package pangenome
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// skippedFeatures are feature types spanning whole sequences, which would
+// label every node of a contig.
+var skippedFeatures = map[string]bool{
+	"region": true,
+	"source": true,
+}
+
+// ReadGFF3 parses a GFF3 file with its sequences in a trailing ##FASTA
+// section. Features take their Name from the Name, gene or locus_tag
+// attribute, in that order, and their Product from the product attribute.
+func ReadGFF3(r io.Reader) (*Annotated, error) {
+	a := &Annotated{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+
+	var features []*Feature
+	headers := make(map[string]string) // seqid: header.
+	fasta := false
+	var seq strings.Builder
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if fasta {
+			if strings.HasPrefix(line, ">") {
+				if len(a.Headers) > 0 {
+					a.Sequences = append(a.Sequences, seq.String())
+					seq.Reset()
+				}
+				fields := strings.Fields(line[1:])
+				if len(fields) == 0 {
+					return nil, fmt.Errorf("gff line %v: FASTA header has no name", n)
+				}
+				a.Headers = append(a.Headers, line)
+				headers[fields[0]] = line
+			} else {
+				seq.WriteString(strings.ToUpper(strings.TrimSpace(line)))
+			}
+			continue
+		}
+		if line == "##FASTA" {
+			fasta = true
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f, err := parseGFFLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("gff line %v: %v", n, err)
+		}
+		if f != nil {
+			features = append(features, f)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(a.Headers) > 0 {
+		a.Sequences = append(a.Sequences, seq.String())
+	}
+	if len(a.Headers) == 0 {
+		return nil, fmt.Errorf("gff has no ##FASTA section")
+	}
+
+	for _, f := range features {
+		header, ok := headers[f.Contig]
+		if !ok {
+			return nil, fmt.Errorf("feature %s is on %s, which has no sequence", f.Label(), f.Contig)
+		}
+		f.Contig = header
+		a.Features = append(a.Features, f)
+	}
+	return a, nil
+}
+
+// parseGFFLine returns the feature on a GFF3 line, with Contig set to its
+// seqid, or nil if it's a skipped type.
+func parseGFFLine(line string) (*Feature, error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 9 {
+		return nil, fmt.Errorf("feature has %v fields, expected 9", len(fields))
+	}
+	if skippedFeatures[fields[2]] {
+		return nil, nil
+	}
+	start, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, err
+	}
+	end, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return nil, err
+	}
+	f := &Feature{
+		Contig: fields[0],
+		Type:   fields[2],
+		Start:  start - 1,
+		End:    end,
+		Strand: fields[6],
+	}
+	attrs := make(map[string]string)
+	for _, attr := range strings.Split(fields[8], ";") {
+		parts := strings.SplitN(attr, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := url.PathUnescape(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		attrs[strings.TrimSpace(parts[0])] = value
+	}
+	f.ID = attrs["ID"]
+	f.Product = attrs["product"]
+	for _, key := range []string{"Name", "gene", "locus_tag"} {
+		if attrs[key] != "" {
+			f.Name = attrs[key]
+			break
+		}
+	}
+	return f, nil
+}
+
+// ReadGenBank parses one or more GenBank records. Each contig's header is
+// built from its VERSION, or its LOCUS name if it has none. Features take
+// their Name from the gene or locus_tag qualifier, their ID from locus_tag
+// and their Product from product. Joined locations are flattened to their
+// outermost bounds.
+func ReadGenBank(r io.Reader) (*Annotated, error) {
+	a := &Annotated{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024*1024)
+
+	var (
+		name     string
+		features []*Feature
+		quals    []map[string]string
+		seq      strings.Builder
+		section  string
+		last     string // last qualifier, for continuation lines.
+		key      string // key of a feature, until its location is complete.
+		location string
+	)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "LOCUS"):
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("genbank line %v: LOCUS has no name", n)
+			}
+			name = fields[1]
+			section = "LOCUS"
+		case strings.HasPrefix(line, "VERSION"):
+			if fields := strings.Fields(line); len(fields) > 1 {
+				name = fields[1]
+			}
+		case strings.HasPrefix(line, "FEATURES"):
+			section = "FEATURES"
+		case strings.HasPrefix(line, "ORIGIN"):
+			section = "ORIGIN"
+		case strings.HasPrefix(line, "//"):
+			if key != "" {
+				return nil, fmt.Errorf("genbank line %v: location %s isn't closed", n, location)
+			}
+			header := ">" + name
+			a.Headers = append(a.Headers, header)
+			a.Sequences = append(a.Sequences, seq.String())
+			for i, f := range features {
+				f.Contig = header
+				f.ID = quals[i]["locus_tag"]
+				f.Product = quals[i]["product"]
+				for _, key := range []string{"gene", "locus_tag"} {
+					if quals[i][key] != "" {
+						f.Name = quals[i][key]
+						break
+					}
+				}
+				if !skippedFeatures[f.Type] {
+					a.Features = append(a.Features, f)
+				}
+			}
+			features, quals = nil, nil
+			seq.Reset()
+			section = ""
+		case section == "FEATURES" && len(line) > 21:
+			value := strings.TrimSpace(line[21:])
+			if k := strings.TrimSpace(line[:21]); k != "" {
+				key, location = k, value
+			} else if key != "" {
+				// NCBI wraps long locations such as join(1..10,
+				// 20..30) over several lines.
+				location += value
+			}
+			if key != "" {
+				if strings.Count(location, "(") > strings.Count(location, ")") {
+					continue
+				}
+				f, err := parseGenBankLocation(location)
+				if err != nil {
+					return nil, fmt.Errorf("genbank line %v: %v", n, err)
+				}
+				f.Type = key
+				features = append(features, f)
+				quals = append(quals, make(map[string]string))
+				key, location, last = "", "", ""
+				continue
+			}
+			if len(features) == 0 {
+				continue
+			}
+			q := quals[len(quals)-1]
+			if strings.HasPrefix(value, "/") {
+				parts := strings.SplitN(value[1:], "=", 2)
+				last = parts[0]
+				if len(parts) == 2 {
+					q[last] = strings.Trim(parts[1], `"`)
+				}
+			} else if last == "translation" {
+				q[last] += strings.Trim(value, `"`)
+			} else if last != "" {
+				// Wrapped text qualifiers continue after a space.
+				q[last] += " " + strings.Trim(value, `"`)
+			}
+		case section == "ORIGIN":
+			for _, field := range strings.Fields(line) {
+				if _, err := strconv.Atoi(field); err != nil {
+					seq.WriteString(strings.ToUpper(field))
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(a.Headers) == 0 {
+		return nil, fmt.Errorf("genbank has no complete records")
+	}
+	return a, nil
+}
+
+// parseGenBankLocation reads the bounds and strand of a feature location such
+// as complement(join(<1..200,300..>400)).
+func parseGenBankLocation(loc string) (*Feature, error) {
+	f := &Feature{
+		Strand: "+",
+		Start:  -1,
+	}
+	if strings.Contains(loc, "complement(") {
+		f.Strand = "-"
+	}
+	clean := strings.NewReplacer("complement(", "", "join(", "", "order(", "", ")", "", "<", "", ">", "").Replace(loc)
+	for _, span := range strings.Split(clean, ",") {
+		bounds := strings.SplitN(span, "..", 2)
+		if len(bounds) == 1 {
+			bounds = append(bounds, bounds[0])
+		}
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("bad location %s", loc)
+		}
+		end, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("bad location %s", loc)
+		}
+		if f.Start == -1 || start-1 < f.Start {
+			f.Start = start - 1
+		}
+		if end > f.End {
+			f.End = end
+		}
+	}
+	return f, nil
+}
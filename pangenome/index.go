@@ -0,0 +1,317 @@
+package pangenome
+
+import (
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Colours is a bitset of sample indices, as returned by Graph.Samples().
+type Colours []uint64
+
+// Set marks sample i as present.
+func (c *Colours) Set(i int) {
+	for len(*c) <= i/64 {
+		*c = append(*c, 0)
+	}
+	(*c)[i/64] |= 1 << uint(i%64)
+}
+
+// Has returns true if sample i is present.
+func (c Colours) Has(i int) bool {
+	if i/64 >= len(c) {
+		return false
+	}
+	return c[i/64]&(1<<uint(i%64)) != 0
+}
+
+// Count returns the number of samples present.
+func (c Colours) Count() int {
+	n := 0
+	for _, w := range c {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Indices returns the indices of the samples present, in order.
+func (c Colours) Indices() []int {
+	var indices []int
+	for i, w := range c {
+		for w != 0 {
+			b := bits.TrailingZeros64(w)
+			indices = append(indices, i*64+b)
+			w &= w - 1
+		}
+	}
+	return indices
+}
+
+// Union returns the samples present in either c or o.
+func (c Colours) Union(o Colours) Colours {
+	if len(c) < len(o) {
+		c, o = o, c
+	}
+	u := append(Colours{}, c...)
+	for i, w := range o {
+		u[i] |= w
+	}
+	return u
+}
+
+// Index is an in-memory view of the graph rebuilt from the k-mer nodes and
+// contig paths stored in Badger. Edge weights are the number of times an edge
+// is traversed by the contig paths, and a node's colours are the samples whose
+// paths visit it.
+type Index struct {
+	K         int
+	Samples   []string
+	Contigs   [][]string            // contig headers per sample index.
+	Paths     []map[string][]uint64 // contig header: path of uids, per sample index.
+	Sequences map[uint64]string
+	Forward   map[uint64]map[uint64]int // src: dst: weight.
+	Reverse   map[uint64]map[uint64]int // dst: src: weight.
+	Colours   map[uint64]Colours
+	Features  []*Feature
+	labels    map[uint64][]*Feature // node: features, built on first use.
+	uids      map[string]uint64     // k-mer: uid, built on first use.
+	next      uint64                // next free uid for AddGenome.
+}
+
+// NewIndex creates an empty index for k-mers of length k.
+func NewIndex(k int) *Index {
+	return &Index{
+		K:         k,
+		Sequences: make(map[uint64]string),
+		Forward:   make(map[uint64]map[uint64]int),
+		Reverse:   make(map[uint64]map[uint64]int),
+		Colours:   make(map[uint64]Colours),
+	}
+}
+
+// LoadIndex reads every sample, contig path, feature and k-mer node into
+// memory.
+func (g *Graph) LoadIndex() (*Index, error) {
+	idx := NewIndex(g.K)
+
+	samples, err := g.Samples()
+	if err != nil {
+		return nil, err
+	}
+	for i, sample := range samples {
+		headers, err := g.SampleContigs(sample)
+		if err != nil {
+			return nil, err
+		}
+		idx.Samples = append(idx.Samples, sample)
+		idx.Contigs = append(idx.Contigs, headers)
+		for _, header := range headers {
+			path, err := g.ContigPath(sample, header)
+			if err != nil {
+				return nil, err
+			}
+			idx.AddPath(i, header, path)
+		}
+		features, err := g.Features(sample)
+		if err != nil {
+			return nil, err
+		}
+		idx.AddFeatures(features)
+	}
+
+	err = g.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(nodePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			uid, err := strconv.ParseUint(strings.TrimPrefix(string(item.Key()), nodePrefix), 10, 64)
+			if err != nil {
+				return err
+			}
+			err = item.Value(func(val []byte) error {
+				idx.Sequences[uid] = string(val)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// AddPath adds a contig path of the sample at the given index, colouring its
+// nodes and weighting its edges. The sample and contig should already be listed
+// in Samples and Contigs.
+func (idx *Index) AddPath(sample int, header string, path []uint64) {
+	for len(idx.Paths) <= sample {
+		idx.Paths = append(idx.Paths, make(map[string][]uint64))
+	}
+	idx.Paths[sample][header] = path
+	for i, uid := range path {
+		c := idx.Colours[uid]
+		c.Set(sample)
+		idx.Colours[uid] = c
+		if i == 0 {
+			continue
+		}
+		src := path[i-1]
+		if idx.Forward[src] == nil {
+			idx.Forward[src] = make(map[uint64]int)
+		}
+		if idx.Reverse[uid] == nil {
+			idx.Reverse[uid] = make(map[uint64]int)
+		}
+		idx.Forward[src][uid]++
+		idx.Reverse[uid][src]++
+	}
+}
+
+// Path returns the path of uids of a contig of the sample at the given index,
+// or nil if there's none.
+func (idx *Index) Path(sample int, header string) []uint64 {
+	if sample >= len(idx.Paths) {
+		return nil
+	}
+	return idx.Paths[sample][header]
+}
+
+// buildLookup fills in the k-mer: uid map the first time it's needed.
+func (idx *Index) buildLookup() {
+	if idx.uids != nil {
+		return
+	}
+	idx.uids = make(map[string]uint64, len(idx.Sequences))
+	for uid, seq := range idx.Sequences {
+		idx.uids[seq] = uid
+		if uid >= idx.next {
+			idx.next = uid + 1
+		}
+	}
+}
+
+// Lookup returns the uid of a k-mer, and false if it isn't in the index.
+func (idx *Index) Lookup(kmer string) (uint64, bool) {
+	idx.buildLookup()
+	uid, ok := idx.uids[kmer]
+	return uid, ok
+}
+
+// AddGenome adds a sample to the index from its contig sequences, giving
+// k-mers not yet in the index new uids. Nothing is written to the store, so a
+// genome can be compared against the graph without changing it.
+func (idx *Index) AddGenome(name string, headers []string, sequences []string) {
+	idx.buildLookup()
+
+	sample := len(idx.Samples)
+	idx.Samples = append(idx.Samples, name)
+	idx.Contigs = append(idx.Contigs, nil)
+	for i, seq := range sequences {
+		if len(seq) < idx.K {
+			continue
+		}
+		path := make([]uint64, 0, len(seq)-idx.K+1)
+		for j := 0; j+idx.K <= len(seq); j++ {
+			kmer := seq[j : j+idx.K]
+			uid, ok := idx.uids[kmer]
+			if !ok {
+				uid = idx.next
+				idx.next++
+				idx.uids[kmer] = uid
+				idx.Sequences[uid] = kmer
+			}
+			path = append(path, uid)
+		}
+		idx.Contigs[sample] = append(idx.Contigs[sample], headers[i])
+		idx.AddPath(sample, headers[i], path)
+	}
+}
+
+// OutDegree returns the number of distinct successors of a node.
+func (idx *Index) OutDegree(uid uint64) int {
+	return len(idx.Forward[uid])
+}
+
+// InDegree returns the number of distinct predecessors of a node.
+func (idx *Index) InDegree(uid uint64) int {
+	return len(idx.Reverse[uid])
+}
+
+// Spell returns the sequence spelled out by a walk of overlapping k-mers.
+func (idx *Index) Spell(path []uint64) string {
+	var sb strings.Builder
+	for i, uid := range path {
+		seq := idx.Sequences[uid]
+		if i == 0 {
+			sb.WriteString(seq)
+		} else if len(seq) > 0 {
+			sb.WriteByte(seq[len(seq)-1])
+		}
+	}
+	return sb.String()
+}
+
+// Nodes returns the uids of every node, sorted.
+func (idx *Index) Nodes() []uint64 {
+	nodes := make([]uint64, 0, len(idx.Sequences))
+	for uid := range idx.Sequences {
+		nodes = append(nodes, uid)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes
+}
+
+// IndexCache keeps a graph's Index loaded for servers answering many queries.
+// It's loaded on first use and reloaded after every write made with Update.
+type IndexCache struct {
+	g   *Graph
+	mu  sync.RWMutex
+	idx *Index
+}
+
+// NewIndexCache creates a cache for a graph, loading nothing yet.
+func NewIndexCache(g *Graph) *IndexCache {
+	return &IndexCache{g: g}
+}
+
+// Index returns the loaded index, loading it if needed.
+func (c *IndexCache) Index() (*Index, error) {
+	c.mu.RLock()
+	idx := c.idx
+	c.mu.RUnlock()
+	if idx != nil {
+		return idx, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.idx == nil {
+		idx, err := c.g.LoadIndex()
+		if err != nil {
+			return nil, err
+		}
+		// Build the lazy lookups now, so concurrent readers only read.
+		idx.Lookup("")
+		idx.Labels(nil)
+		c.idx = idx
+	}
+	return c.idx, nil
+}
+
+// Update runs write alone, with no index loaded or load under way, and drops
+// the index afterwards, even if write fails, in case part of it was stored.
+func (c *IndexCache) Update(write func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer func() { c.idx = nil }()
+	return write()
+}
@@ -0,0 +1,409 @@
+package pangenome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/dgo/protos/api"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+const ingestPrefix = "ingest/" // ingest/<sample>: progress of a sample added by Build.
+
+// Build phases of a sample, in order.
+const (
+	ingestNodes  = "nodes"  // creating nodes, edges and contig paths.
+	ingestSample = "sample" // registered; annotating and updating unitigs.
+	ingestDone   = "done"
+)
+
+// BuildResult is what Build did with a sample.
+type BuildResult int
+
+const (
+	BuildAdded   BuildResult = iota // added from scratch.
+	BuildSkipped                    // already built.
+	BuildResumed                    // finished after an interrupted build.
+)
+
+func (r BuildResult) String() string {
+	switch r {
+	case BuildAdded:
+		return "added"
+	case BuildSkipped:
+		return "skipped"
+	case BuildResumed:
+		return "resumed"
+	}
+	return "BuildResult(" + strconv.Itoa(int(r)) + ")"
+}
+
+// ingestCheckpoint is the progress of a sample added by Build. It's written
+// after each contig's path, so at most one contig is redone when resuming.
+type ingestCheckpoint struct {
+	Source  string `json:"source"`
+	Phase   string `json:"phase"`
+	Contigs int    `json:"contigs"`            // contigs with stored paths.
+	NextUID uint64 `json:"next_uid,omitempty"` // first uid of the sample in an embedded graph.
+}
+
+func (cp *ingestCheckpoint) save(g *Graph, name string) error {
+	buf, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	_, err = g.SetKVStr(ingestPrefix+name, string(buf))
+	return err
+}
+
+// ingestCheckpoint returns the checkpoint of a sample, or nil if Build
+// hasn't started it.
+func (g *Graph) ingestCheckpoint(name string) (*ingestCheckpoint, error) {
+	buf, err := g.GetKVStr(ingestPrefix + name)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cp ingestCheckpoint
+	if err := json.Unmarshal([]byte(buf), &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Build adds a genome read from source like AddAnnotated, logging its
+// progress in Badger as it goes. With resume, samples already built from the
+// same source are skipped and interrupted ones finished, so a series of
+// Builds gives the same graph however often it's interrupted; without it,
+// any sample Build has seen before is refused.
+//
+// While a sample is interrupted creating its nodes, no other genome can be
+// added by Build or AddGenome until it's resumed, so the nodes created since
+// it started are all its own. An embedded graph rolls an interrupted sample
+// back, removing those nodes, and adds it again so nodes get the uids they
+// would have had. With
+// Dgraph, contigs whose paths were stored are skipped and the rest added
+// again, reusing any nodes already created; a node Dgraph created just before
+// the interruption, which Badger never recorded, is found by reconcileNodes
+// and reused too.
+func (g *Graph) Build(name string, source string, a *Annotated, resume bool, contextMain context.Context) (BuildResult, error) {
+	ctx, cancel := context.WithCancel(contextMain)
+	defer cancel()
+
+	cp, err := g.ingestCheckpoint(name)
+	if err != nil {
+		return 0, err
+	}
+	result := BuildAdded
+	switch {
+	case cp == nil:
+		exists, err := g.HasSample(name)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			return 0, fmt.Errorf("sample %s already exists", name)
+		}
+		if len(a.Sequences) == 0 {
+			return 0, fmt.Errorf("sample %s has no sequences", name)
+		}
+		if err := g.checkPartialBuilds(); err != nil {
+			return 0, err
+		}
+		cp = &ingestCheckpoint{Source: source, Phase: ingestNodes}
+		if g.embedded {
+			if cp.NextUID, err = g.peekUID(); err != nil {
+				return 0, err
+			}
+		}
+		if err := cp.save(g, name); err != nil {
+			return 0, err
+		}
+	case cp.Source != source:
+		return 0, fmt.Errorf("sample %s was built from %s, not %s", name, cp.Source, source)
+	case !resume && cp.Phase == ingestDone:
+		return 0, fmt.Errorf("sample %s already exists", name)
+	case !resume:
+		return 0, fmt.Errorf("sample %s was only partly built; resume the build to finish it", name)
+	case cp.Phase == ingestDone:
+		return BuildSkipped, nil
+	default:
+		result = BuildResumed
+	}
+
+	if cp.Phase == ingestNodes {
+		// The checkpoint may have been left behind by a sample that was
+		// registered just before an interruption.
+		exists, err := g.HasSample(name)
+		if err != nil {
+			return 0, err
+		}
+		if exists {
+			cp.Phase = ingestSample
+		}
+	}
+	if cp.Phase == ingestNodes {
+		if result == BuildResumed && g.embedded {
+			if err := g.rollbackNodes(cp.NextUID); err != nil {
+				return 0, err
+			}
+			cp.Contigs = 0
+		}
+		km := kmers.NewFromSequences(a.Headers, a.Sequences)
+		km.K = g.K
+		headers, err := g.addNodes(name, km, cp.Contigs, func(contigs int) error {
+			cp.Contigs = contigs
+			return cp.save(g, name)
+		}, ctx)
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if err := g.AddSample(name, headers); err != nil {
+			return 0, err
+		}
+		cp.Phase = ingestSample
+		if err := cp.save(g, name); err != nil {
+			return 0, err
+		}
+	}
+
+	// Once registered, the sample is finished regardless of ctx. Both steps
+	// replace what they write, so they're simply redone when resuming.
+	if a.Features != nil {
+		if err := g.Annotate(name, a.Features); err != nil {
+			return 0, err
+		}
+	}
+	if err := g.updateCompacted(name); err != nil {
+		return 0, err
+	}
+	cp.Phase = ingestDone
+	if err := cp.save(g, name); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// Built returns true if Build has finished adding the sample from source.
+func (g *Graph) Built(name string, source string) (bool, error) {
+	cp, err := g.ingestCheckpoint(name)
+	if err != nil || cp == nil {
+		return false, err
+	}
+	return cp.Source == source && cp.Phase == ingestDone, nil
+}
+
+// checkPartialBuilds refuses with the name of a sample Build was interrupted
+// in while creating its nodes, if there's one.
+func (g *Graph) checkPartialBuilds() error {
+	var partial []string
+	err := g.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(ingestPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			var cp ingestCheckpoint
+			err := item.Value(func(val []byte) error {
+				return json.Unmarshal(val, &cp)
+			})
+			if err != nil {
+				return err
+			}
+			if cp.Phase == ingestNodes {
+				partial = append(partial, strings.TrimPrefix(string(item.Key()), ingestPrefix))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range partial {
+		// Registered just before an interruption, so its nodes are done.
+		exists, err := g.HasSample(name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("sample %s was only partly built; resume its build before adding other genomes", name)
+		}
+	}
+	return nil
+}
+
+// peekUID returns the uid nextUID would hand out, without taking it.
+func (g *Graph) peekUID() (uint64, error) {
+	s, err := g.GetKVStr(nextUIDKey)
+	if err == badger.ErrKeyNotFound {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// rollbackNodes removes every node of an embedded graph with a uid of at
+// least first and hands out uids from first again. Contig paths referring to
+// them are left to be overwritten.
+func (g *Graph) rollbackNodes(first uint64) error {
+	var keys [][]byte
+	err := g.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(nodePrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			uid, err := strconv.ParseUint(strings.TrimPrefix(string(item.Key()), nodePrefix), 10, 64)
+			if err != nil {
+				return err
+			}
+			if uid < first {
+				continue
+			}
+			err = item.Value(func(val []byte) error {
+				keys = append(keys, item.KeyCopy(nil), []byte(kmerPrefix+string(val)))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	b := g.newBatch()
+	for _, key := range keys {
+		if err := b.delete(key); err != nil {
+			return err
+		}
+	}
+	if err := b.set([]byte(nextUIDKey), []byte(strconv.FormatUint(first, 10))); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// reconcileNodes settles the k-mers UpsertNode left pending when interrupted
+// between creating a Dgraph node and recording it in Badger. The node is
+// looked up by its sequence and recorded, so it's reused rather than left
+// behind; any others with the same sequence are deleted.
+func (g *Graph) reconcileNodes(ctx context.Context) error {
+	var seqs []string
+	err := g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix := []byte(pendingPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			seqs = append(seqs, strings.TrimPrefix(string(it.Item().Key()), pendingPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, seq := range seqs {
+		recorded, err := g.LookupKmers([]string{seq})
+		if err != nil {
+			return err
+		}
+		uids, err := g.dgraphNodes(seq, ctx)
+		if err != nil {
+			return err
+		}
+		keep, ok := recorded[seq]
+		if !ok && len(uids) > 0 {
+			keep = uids[0]
+		}
+		var orphans []uint64
+		for _, uid := range uids {
+			if uid != keep {
+				orphans = append(orphans, uid)
+			}
+		}
+		if err := g.deleteDgraphNodes(orphans, ctx); err != nil {
+			return err
+		}
+		err = g.bd.Update(func(txn *badger.Txn) error {
+			if !ok && keep != 0 {
+				if err := txn.Set([]byte(kmerPrefix+seq), []byte(strconv.FormatUint(keep, 10))); err != nil {
+					return err
+				}
+				if err := txn.Set([]byte(nodeKey(keep)), []byte(seq)); err != nil {
+					return err
+				}
+			}
+			return txn.Delete([]byte(pendingPrefix + seq))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dgraphNodes returns the uids of every Dgraph node for seq.
+func (g *Graph) dgraphNodes(seq string, ctx context.Context) ([]uint64, error) {
+	txn := g.dg.NewReadOnlyTxn()
+	defer txn.Discard(ctx)
+
+	q := `query q($seq: string) {
+		q(func: eq(sequence, $seq)) {
+			uid
+		}
+	}`
+	resp, err := txn.QueryWithVars(ctx, q, map[string]string{"$seq": seq})
+	if err != nil {
+		return nil, err
+	}
+	var decode struct {
+		Q []struct {
+			UID string `json:"uid"`
+		} `json:"q"`
+	}
+	if err := json.Unmarshal(resp.GetJson(), &decode); err != nil {
+		return nil, err
+	}
+	uids := make([]uint64, 0, len(decode.Q))
+	for _, n := range decode.Q {
+		uid, err := strconv.ParseUint(strings.TrimPrefix(n.UID, "0x"), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		uids = append(uids, uid)
+	}
+	return uids, nil
+}
+
+// deleteDgraphNodes deletes nodes from Dgraph along with their edges.
+func (g *Graph) deleteDgraphNodes(uids []uint64, ctx context.Context) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	nodes := make([]map[string]string, len(uids))
+	for i, uid := range uids {
+		nodes[i] = map[string]string{"uid": "0x" + strconv.FormatUint(uid, 16)}
+	}
+	nb, err := json.Marshal(nodes)
+	if err != nil {
+		return err
+	}
+	txn := g.dg.NewTxn()
+	defer txn.Discard(ctx)
+	_, err = txn.Mutate(ctx, &api.Mutation{DeleteJson: nb, CommitNow: true})
+	return err
+}
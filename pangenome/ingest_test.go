@@ -0,0 +1,131 @@
+package pangenome
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/dgraph-io/badger"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+// dumpKV returns every key and value in Badger.
+func dumpKV(g *Graph) map[string]string {
+	kv := make(map[string]string)
+	g.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			item.Value(func(val []byte) error {
+				kv[string(item.Key())] = string(val)
+				return nil
+			})
+		}
+		return nil
+	})
+	return kv
+}
+
+func ExampleGraph_Build() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	genomes := []struct {
+		name string
+		a    *Annotated
+	}{
+		{"a", &Annotated{Headers: []string{">a"}, Sequences: []string{"GATTACAGCGTCCATGG"}}},
+		{"b", &Annotated{Headers: []string{">b1", ">b2"}, Sequences: []string{"GATTACAGCTTCCATGG", "CCCGGGTTTAAA"}}},
+	}
+
+	// An uninterrupted build.
+	want, err := openTestGraph(filepath.Join(dir, "want"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer want.Close()
+	for _, genome := range genomes {
+		if _, err := want.Build(genome.name, genome.name+".fasta", genome.a, false, ctx); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	// One interrupted in b after its first contig, having taken a uid for a
+	// node of the second without recording it.
+	g, err := openTestGraph(filepath.Join(dir, "got"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	if _, err := g.Build("a", "a.fasta", genomes[0].a, false, ctx); err != nil {
+		fmt.Println(err)
+		return
+	}
+	cp := &ingestCheckpoint{Source: "b.fasta", Phase: ingestNodes}
+	cp.NextUID, _ = g.peekUID()
+	cp.save(g, "b")
+	km := kmers.NewFromSequences([]string{">b1"}, []string{"GATTACAGCTTCCATGG"})
+	km.K = g.K
+	g.createAll("b", km, 0, func(contigs int) error {
+		cp.Contigs = contigs
+		return cp.save(g, "b")
+	}, ctx)
+	g.nextUID()
+
+	// Nothing else is added until b is resumed, as resuming removes every
+	// node created since b started.
+	c := &Annotated{Headers: []string{">c1"}, Sequences: []string{"TTTTTGGGGGAAAAACCCCC"}}
+	_, err = g.AddAnnotated("c", c, ctx)
+	fmt.Println(err)
+	_, err = g.Build("c", "c.fasta", c, true, ctx)
+	fmt.Println(err)
+
+	_, err = g.Build("b", "b.fasta", genomes[1].a, false, ctx)
+	fmt.Println(err)
+	_, err = g.Build("b", "other.fasta", genomes[1].a, true, ctx)
+	fmt.Println(err)
+	for _, genome := range genomes {
+		result, err := g.Build(genome.name, genome.name+".fasta", genome.a, true, ctx)
+		fmt.Println(genome.name, result, err)
+	}
+	fmt.Println(reflect.DeepEqual(dumpKV(g), dumpKV(want)))
+
+	result, err := g.Build("c", "c.fasta", c, true, ctx)
+	fmt.Println("c", result, err)
+	idx, err := g.LoadIndex()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i, sample := range idx.Samples {
+		headers, _ := g.SampleContigs(sample)
+		for _, header := range headers {
+			fmt.Println(sample, header, idx.Spell(idx.Path(i, header)))
+		}
+	}
+	// Output:
+	// sample b was only partly built; resume its build before adding other genomes
+	// sample b was only partly built; resume its build before adding other genomes
+	// sample b was only partly built; resume the build to finish it
+	// sample b was built from b.fasta, not other.fasta
+	// a skipped <nil>
+	// b resumed <nil>
+	// true
+	// c added <nil>
+	// a >a GATTACAGCGTCCATGG
+	// b >b1 GATTACAGCTTCCATGG
+	// b >b2 CCCGGGTTTAAA
+	// c >c1 TTTTTGGGGGAAAAACCCCC
+}
@@ -0,0 +1,285 @@
+package pangenome
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+// Location is where a query sequence sits in the pangenome. Coverage is the
+// fraction of the query's k-mers found in the graph on either strand, and
+// Breaks are the stretches of the query, in base coordinates, spanned by
+// k-mers missing from the graph.
+type Location struct {
+	Kmers    int              `json:"kmers"`
+	Found    int              `json:"found"`
+	Coverage float64          `json:"coverage"`
+	Breaks   []Interval       `json:"breaks"`
+	Samples  []SampleLocation `json:"samples"`
+}
+
+// Interval is a half-open, 0-based range of bases.
+type Interval struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// SampleLocation is where a query matches in one sample. Identity is the
+// percentage of the query's k-mers found in any of the sample's contigs.
+type SampleLocation struct {
+	Sample    string    `json:"sample"`
+	Identity  float64   `json:"identity"`
+	Stretches []Stretch `json:"stretches"`
+}
+
+// Stretch is a run of consecutive query k-mers matching consecutive k-mers of
+// a contig. Strand is "-" when the query matches the reverse complement.
+type Stretch struct {
+	Contig string   `json:"contig"`
+	Strand string   `json:"strand"`
+	Query  Interval `json:"query"`
+	Target Interval `json:"target"`
+}
+
+// pathSource calls fn with every contig path of every sample.
+type pathSource func(fn func(sample string, contig string, path []uint64) error) error
+
+// LookupKmers returns the uid of each k-mer found in the graph, looked up
+// together in a single Badger transaction.
+func (g *Graph) LookupKmers(seqs []string) (map[string]uint64, error) {
+	found := make(map[string]uint64, len(seqs))
+	err := g.bd.View(func(txn *badger.Txn) error {
+		for _, seq := range seqs {
+			if _, ok := found[seq]; ok {
+				continue
+			}
+			item, err := txn.Get([]byte(kmerPrefix + seq))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			var uid uint64
+			err = item.Value(func(val []byte) error {
+				_, err := fmt.Sscan(string(val), &uid)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			found[seq] = uid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// Locate finds a query sequence in the pangenome. The query's k-mers are
+// looked up in bulk, then each sample's contig paths are read once to place
+// them.
+func (g *Graph) Locate(seq string) (*Location, error) {
+	fwd, rev := queryKmers(seq, g.K)
+	found, err := g.LookupKmers(append(append([]string{}, fwd...), rev...))
+	if err != nil {
+		return nil, err
+	}
+	paths := func(fn func(string, string, []uint64) error) error {
+		samples, err := g.Samples()
+		if err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			headers, err := g.SampleContigs(sample)
+			if err != nil {
+				return err
+			}
+			for _, header := range headers {
+				path, err := g.ContigPath(sample, header)
+				if err != nil {
+					return err
+				}
+				if err := fn(sample, header, path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return locate(fwd, rev, g.K, found, paths)
+}
+
+// Locate finds a query sequence in the index; see Graph.Locate.
+func (idx *Index) Locate(seq string) *Location {
+	fwd, rev := queryKmers(seq, idx.K)
+	found := make(map[string]uint64)
+	for _, kmer := range append(append([]string{}, fwd...), rev...) {
+		if uid, ok := idx.Lookup(kmer); ok {
+			found[kmer] = uid
+		}
+	}
+	paths := func(fn func(string, string, []uint64) error) error {
+		for i, sample := range idx.Samples {
+			for _, contig := range idx.Contigs[i] {
+				if err := fn(sample, contig, idx.Path(i, contig)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	// The index is in memory, so paths never fail.
+	loc, _ := locate(fwd, rev, idx.K, found, paths)
+	return loc
+}
+
+// queryKmers splits a query and its reverse complement into k-mers.
+func queryKmers(seq string, k int) ([]string, []string) {
+	rc := kmers.ReverseComplement(seq)
+	var fwd, rev []string
+	for i := 0; i+k <= len(seq); i++ {
+		fwd = append(fwd, seq[i:i+k])
+		rev = append(rev, rc[i:i+k])
+	}
+	return fwd, rev
+}
+
+type hit struct {
+	contig string
+	strand string
+	diag   int // target position - query position.
+}
+
+func locate(fwd []string, rev []string, k int, found map[string]uint64, paths pathSource) (*Location, error) {
+	n := len(fwd)
+	loc := &Location{
+		Kmers: n,
+	}
+	if n == 0 {
+		return loc, nil
+	}
+
+	// Query positions of each node, on the forward strand and on the reverse
+	// complement (as positions in the reverse complement).
+	fwdPos := make(map[uint64][]int)
+	revPos := make(map[uint64][]int)
+	start := -1
+	for j := 0; j < n; j++ {
+		f, okF := found[fwd[j]]
+		r, okR := found[rev[j]]
+		if okF {
+			fwdPos[f] = append(fwdPos[f], j)
+		}
+		if okR {
+			revPos[r] = append(revPos[r], j)
+		}
+		// rev[n-1-j] is the reverse complement of fwd[j].
+		if _, ok := found[rev[n-1-j]]; okF || ok {
+			loc.Found++
+			if start != -1 {
+				loc.Breaks = append(loc.Breaks, Interval{start, j - 1 + k})
+				start = -1
+			}
+		} else if start == -1 {
+			start = j
+		}
+	}
+	if start != -1 {
+		loc.Breaks = append(loc.Breaks, Interval{start, n - 1 + k})
+	}
+	loc.Coverage = float64(loc.Found) / float64(n)
+
+	bySample := make(map[string]*SampleLocation)
+	covered := make(map[string]map[int]bool) // sample: query positions found.
+	var order []string
+	err := paths(func(sample string, contig string, path []uint64) error {
+		hits := make(map[hit][]int) // hit: query positions, in order.
+		matched := make(map[int]bool)
+		for i, uid := range path {
+			for _, j := range fwdPos[uid] {
+				h := hit{contig, "+", i - j}
+				hits[h] = append(hits[h], j)
+				matched[j] = true
+			}
+			for _, j := range revPos[uid] {
+				h := hit{contig, "-", i - j}
+				hits[h] = append(hits[h], j)
+				matched[n-1-j] = true
+			}
+		}
+		if len(hits) == 0 {
+			return nil
+		}
+		sl, ok := bySample[sample]
+		if !ok {
+			sl = &SampleLocation{Sample: sample}
+			bySample[sample] = sl
+			covered[sample] = make(map[int]bool)
+			order = append(order, sample)
+		}
+		sl.Stretches = append(sl.Stretches, stretches(hits, n, k)...)
+		// A query k-mer found in several contigs only counts once.
+		for j := range matched {
+			covered[sample][j] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sample := range order {
+		sl := bySample[sample]
+		sl.Identity = 100 * float64(len(covered[sample])) / float64(n)
+		sort.Slice(sl.Stretches, func(a, b int) bool {
+			sa, sb := sl.Stretches[a], sl.Stretches[b]
+			if sa.Contig != sb.Contig {
+				return sa.Contig < sb.Contig
+			}
+			if sa.Target.Start != sb.Target.Start {
+				return sa.Target.Start < sb.Target.Start
+			}
+			if sa.Strand != sb.Strand {
+				return sa.Strand < sb.Strand
+			}
+			return sa.Query.Start < sb.Query.Start
+		})
+		loc.Samples = append(loc.Samples, *sl)
+	}
+	return loc, nil
+}
+
+// stretches chains runs of consecutive query positions on the same diagonal.
+func stretches(hits map[hit][]int, n int, k int) []Stretch {
+	var out []Stretch
+	for h, positions := range hits {
+		sort.Ints(positions)
+		first := positions[0]
+		for i := 1; i <= len(positions); i++ {
+			if i < len(positions) && positions[i] == positions[i-1]+1 {
+				continue
+			}
+			last := positions[i-1]
+			s := Stretch{
+				Contig: h.contig,
+				Strand: h.strand,
+				Query:  Interval{first, last + k},
+				Target: Interval{first + h.diag, last + h.diag + k},
+			}
+			if h.strand == "-" {
+				// Positions are in the reverse complement of the query.
+				s.Query = Interval{n - 1 - last, n - 1 - first + k}
+			}
+			out = append(out, s)
+			if i < len(positions) {
+				first = positions[i]
+			}
+		}
+	}
+	return out
+}
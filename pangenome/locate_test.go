@@ -0,0 +1,46 @@
+package pangenome
+
+import (
+	"fmt"
+)
+
+func ExampleIndex_Locate() {
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"TTGATTACAGCGTCCATGG"})
+	idx.AddGenome("b", []string{">b"}, []string{"CCATGGACGCTGTAATCAA"})
+
+	loc := idx.Locate("GATTACAGCTTCCATGG")
+	fmt.Printf("%v/%v %.2f %v\n", loc.Found, loc.Kmers, loc.Coverage, loc.Breaks)
+	for _, s := range loc.Samples {
+		fmt.Printf("%s %.1f\n", s.Sample, s.Identity)
+		for _, st := range s.Stretches {
+			fmt.Println(st.Contig, st.Strand, st.Query, st.Target)
+		}
+	}
+	// Output:
+	// 8/13 0.62 [{5 14}]
+	// a 61.5
+	// >a + {0 9} {2 11}
+	// >a + {10 17} {12 19}
+	// >a - {11 17} {13 19}
+	// b 61.5
+	// >b + {11 17} {0 6}
+	// >b - {10 17} {0 7}
+	// >b - {0 9} {8 17}
+}
+
+func ExampleIndex_Locate_repeated() {
+	// The first half of the query is in both of a's contigs, the second half
+	// in neither.
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a1", ">a2"}, []string{"GATTACAGCG", "CCGATTACAGCG"})
+
+	loc := idx.Locate("GATTACAGCGAAAAAAAAAA")
+	fmt.Printf("%v/%v %.2f\n", loc.Found, loc.Kmers, loc.Coverage)
+	for _, s := range loc.Samples {
+		fmt.Printf("%s %.1f\n", s.Sample, s.Identity)
+	}
+	// Output:
+	// 6/16 0.38
+	// a 37.5
+}
@@ -0,0 +1,510 @@
+package pangenome
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger"
+)
+
+const (
+	migratePrefix        = "migrate/" // migrate/...: progress of an unfinished migration into this store.
+	migrateCheckpointKey = migratePrefix + "checkpoint"
+	migrateUIDPrefix     = migratePrefix + "uid/" // migrate/uid/<source uid>: uid in Dgraph.
+)
+
+// Migration phases, in order.
+const (
+	migrateNodes = "nodes"
+	migrateEdges = "edges"
+	migrateKV    = "kv"
+	migrateDone  = "done"
+)
+
+// ParseBackend reads the options for a backend given as a URL:
+//
+//	dgraph://host:port?badger=<dir>  Dgraph, with Badger in dir (default badger)
+//	badger://<dir>                   an embedded graph in dir; badger:///<dir> for absolute paths
+func ParseBackend(s string) (Options, error) {
+	opts := DefaultOptions
+	u, err := url.Parse(s)
+	if err != nil {
+		return opts, err
+	}
+	switch u.Scheme {
+	case "dgraph":
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			host, port = u.Host, DefaultOptions.DgraphPort
+		}
+		if host == "" {
+			return opts, fmt.Errorf("%s has no Dgraph host", s)
+		}
+		opts.DgraphHost, opts.DgraphPort = host, port
+		if dir := u.Query().Get("badger"); dir != "" {
+			opts.Dir = dir
+		}
+	case "badger":
+		opts.Embedded = true
+		opts.Dir = u.Host + u.Path
+		if opts.Dir == "" {
+			return opts, fmt.Errorf("%s has no directory", s)
+		}
+	default:
+		return opts, fmt.Errorf("backend %s must start with dgraph:// or badger://", s)
+	}
+	return opts, nil
+}
+
+// MigrateOptions control a migration.
+type MigrateOptions struct {
+	Checkpoint int   // records copied between checkpoints.
+	Verify     int   // nodes whose neighbourhoods are compared afterwards.
+	Seed       int64 // picks the nodes to verify.
+}
+
+// DefaultMigrateOptions checkpoint every 10000 records and verify 100 nodes.
+var DefaultMigrateOptions = MigrateOptions{
+	Checkpoint: 10000,
+	Verify:     100,
+	Seed:       1,
+}
+
+// MigrateReport summarises a finished migration.
+type MigrateReport struct {
+	Resumed  bool
+	Samples  int
+	Nodes    int
+	Edges    int
+	Keys     int
+	Verified int // nodes whose neighbourhoods matched.
+}
+
+// migrateCheckpoint is the progress of a migration, kept in the target.
+type migrateCheckpoint struct {
+	Source string `json:"source"`
+	Phase  string `json:"phase"`
+	Last   string `json:"last"`   // last key copied in the nodes or kv phase.
+	Sample int    `json:"sample"` // next sample and contig in the edges phase.
+	Contig int    `json:"contig"`
+	MaxUID uint64 `json:"max_uid"`
+}
+
+// save writes the checkpoint with the batch and flushes it, so the
+// checkpoint never runs ahead of the records it covers.
+func (cp *migrateCheckpoint) save(b *batch) error {
+	buf, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if err := b.set([]byte(migrateCheckpointKey), buf); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// Migrate copies every node, edge, contig path and key from src into dst,
+// which must be empty or hold an unfinished migration from the same source.
+// src only needs Badger; dst gets its nodes and edges recreated in Dgraph,
+// with uids rewritten as in Restore, unless it's embedded. Progress is
+// checkpointed in dst so an interrupted migration resumes where it stopped.
+// Once copied, the graphs are compared by VerifyMigration.
+func Migrate(src *Graph, dst *Graph, source string, opts MigrateOptions, contextMain context.Context) (*MigrateReport, error) {
+	ctx, cancel := context.WithCancel(contextMain)
+	defer cancel()
+	if opts.Checkpoint < 1 {
+		opts.Checkpoint = 1
+	}
+
+	report := &MigrateReport{}
+	var cp *migrateCheckpoint
+	buf, err := dst.GetKVStr(migrateCheckpointKey)
+	switch err {
+	case nil:
+		if err := json.Unmarshal([]byte(buf), &cp); err != nil {
+			return nil, err
+		}
+		if cp.Source != source {
+			return nil, fmt.Errorf("store holds an unfinished migration from %s", cp.Source)
+		}
+		report.Resumed = true
+	case badger.ErrKeyNotFound:
+		empty, err := dst.empty()
+		if err != nil {
+			return nil, err
+		}
+		if !empty {
+			return nil, fmt.Errorf("store is not empty")
+		}
+		cp = &migrateCheckpoint{Source: source, Phase: migrateNodes}
+		if err := cp.save(dst.newBatch()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	uids := make(map[uint64]uint64)
+	if !dst.embedded {
+		err := dst.bd.View(func(txn *badger.Txn) error {
+			it := txn.NewIterator(badger.DefaultIteratorOptions)
+			defer it.Close()
+			prefix := []byte(migrateUIDPrefix)
+			for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+				item := it.Item()
+				uid, err := strconv.ParseUint(strings.TrimPrefix(string(item.Key()), migrateUIDPrefix), 10, 64)
+				if err != nil {
+					return err
+				}
+				err = item.Value(func(val []byte) error {
+					uids[uid], err = strconv.ParseUint(string(val), 10, 64)
+					return err
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cp.Phase == migrateNodes {
+		if err := migrateNodesPhase(src, dst, cp, uids, opts, ctx); err != nil {
+			return nil, err
+		}
+	}
+	if cp.Phase == migrateEdges {
+		if err := migrateEdgesPhase(src, dst, cp, uids, ctx); err != nil {
+			return nil, err
+		}
+	}
+	if cp.Phase == migrateKV {
+		if err := migrateKVPhase(src, dst, cp, uids, opts, ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Stores from before k was recorded don't hold it to copy.
+	dst.K = src.K
+	if err := dst.recordK(); err != nil {
+		return nil, err
+	}
+
+	v, err := VerifyMigration(src, dst, opts.Verify, opts.Seed)
+	if err != nil {
+		return nil, err
+	}
+	report.Samples, report.Nodes, report.Edges = v.Samples, v.Nodes, v.Edges
+	report.Keys, report.Verified = v.Keys, v.Verified
+	if err := dst.dropPrefix(migratePrefix); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// migrateNodesPhase creates a Dgraph node for every source node, recording
+// its new uid, and finds the largest uid.
+func migrateNodesPhase(src *Graph, dst *Graph, cp *migrateCheckpoint, uids map[uint64]uint64, opts MigrateOptions, ctx context.Context) error {
+	b := dst.newBatch()
+	n := 0
+	err := src.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(nodePrefix)
+		start := prefix
+		if cp.Last != "" {
+			start = []byte(cp.Last)
+		}
+		for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			key := string(item.Key())
+			if key == cp.Last {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			uid, err := strconv.ParseUint(strings.TrimPrefix(key, nodePrefix), 10, 64)
+			if err != nil {
+				return err
+			}
+			if uid > cp.MaxUID {
+				cp.MaxUID = uid
+			}
+			if _, ok := uids[uid]; !ok && !dst.embedded {
+				var seq string
+				err := item.Value(func(val []byte) error {
+					seq = string(val)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				// A crash before the next checkpoint leaves this node
+				// orphaned in Dgraph, unreachable from any k-mer.
+				created, err := dst.CreateNode(seq, ctx)
+				if err != nil {
+					return err
+				}
+				uids[uid] = created
+				if err := b.set([]byte(migrateUIDPrefix+strconv.FormatUint(uid, 10)), []byte(strconv.FormatUint(created, 10))); err != nil {
+					return err
+				}
+			}
+			cp.Last = key
+			n++
+			if n%opts.Checkpoint == 0 {
+				if err := cp.save(b); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cp.Phase, cp.Last = migrateEdges, ""
+	return cp.save(b)
+}
+
+// migrateEdgesPhase creates the Dgraph edges along every contig path. Edges
+// are set idempotently, so a contig redone after a crash is harmless.
+func migrateEdgesPhase(src *Graph, dst *Graph, cp *migrateCheckpoint, uids map[uint64]uint64, ctx context.Context) error {
+	if !dst.embedded {
+		samples, err := src.Samples()
+		if err != nil {
+			return err
+		}
+		seen := make(map[[2]uint64]bool)
+		for ; cp.Sample < len(samples); cp.Sample, cp.Contig = cp.Sample+1, 0 {
+			headers, err := src.SampleContigs(samples[cp.Sample])
+			if err != nil {
+				return err
+			}
+			for ; cp.Contig < len(headers); cp.Contig++ {
+				path, err := src.ContigPath(samples[cp.Sample], headers[cp.Contig])
+				if err != nil {
+					return err
+				}
+				for i := 1; i < len(path); i++ {
+					edge := [2]uint64{path[i-1], path[i]}
+					if seen[edge] {
+						continue
+					}
+					seen[edge] = true
+					from, ok := uids[edge[0]]
+					to, ok2 := uids[edge[1]]
+					if !ok || !ok2 {
+						return fmt.Errorf("contig %s visits a node missing from the source", headers[cp.Contig])
+					}
+					if _, err := dst.CreateEdge(from, to, ctx); err != nil {
+						return err
+					}
+				}
+				saved := *cp
+				saved.Contig++
+				if err := saved.save(dst.newBatch()); err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	cp.Phase = migrateKV
+	return cp.save(dst.newBatch())
+}
+
+// migrateKVPhase copies every Badger key, rewriting uids for Dgraph.
+func migrateKVPhase(src *Graph, dst *Graph, cp *migrateCheckpoint, uids map[uint64]uint64, opts MigrateOptions, ctx context.Context) error {
+	b := dst.newBatch()
+	n := 0
+	err := src.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		if cp.Last == "" {
+			it.Rewind()
+		} else {
+			it.Seek([]byte(cp.Last))
+		}
+		for ; it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.KeyCopy(nil)
+			if string(key) == cp.Last || string(key) == nextUIDKey || strings.HasPrefix(string(key), migratePrefix) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			if !dst.embedded {
+				// The source was opened at the current schema, so contig
+				// paths are all under path/.
+				if key, val, err = remapRecord(uids, nil, key, val); err != nil {
+					return err
+				}
+			}
+			if err := b.set(key, val); err != nil {
+				return err
+			}
+			cp.Last = string(item.Key())
+			n++
+			if n%opts.Checkpoint == 0 {
+				if err := cp.save(b); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if dst.embedded && cp.MaxUID > 0 {
+		// Carry on counting from the largest uid, which may have come from
+		// Dgraph.
+		if err := b.set([]byte(nextUIDKey), []byte(strconv.FormatUint(cp.MaxUID+1, 10))); err != nil {
+			return err
+		}
+	}
+	cp.Phase, cp.Last = migrateDone, ""
+	return cp.save(b)
+}
+
+// Verification is the outcome of comparing two graphs.
+type Verification struct {
+	Samples  int
+	Nodes    int
+	Edges    int
+	Keys     int // Badger keys, besides uid counters, k and migration progress.
+	Verified int
+}
+
+// neighbourhood is a node's edges and colours in terms of k-mers and sample
+// names, which don't depend on uids.
+type neighbourhood struct {
+	Forward map[string]int
+	Reverse map[string]int
+	Samples []string
+}
+
+func newNeighbourhood(idx *Index, uid uint64) neighbourhood {
+	n := neighbourhood{
+		Forward: make(map[string]int),
+		Reverse: make(map[string]int),
+	}
+	for dst, w := range idx.Forward[uid] {
+		n.Forward[idx.Sequences[dst]] = w
+	}
+	for src, w := range idx.Reverse[uid] {
+		n.Reverse[idx.Sequences[src]] = w
+	}
+	for _, i := range idx.Colours[uid].Indices() {
+		n.Samples = append(n.Samples, idx.Samples[i])
+	}
+	return n
+}
+
+// VerifyMigration compares two graphs: their samples and contigs, the
+// numbers of nodes, edges and keys, and the neighbourhoods of n nodes of a,
+// picked at random with the seed, which must have the same k-mer
+// neighbours, edge weights and samples in b.
+func VerifyMigration(a *Graph, b *Graph, n int, seed int64) (*Verification, error) {
+	ia, err := a.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	ib, err := b.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(ia.Samples, ib.Samples) || !reflect.DeepEqual(ia.Contigs, ib.Contigs) {
+		return nil, fmt.Errorf("samples or their contigs differ")
+	}
+	v := &Verification{
+		Samples: len(ia.Samples),
+		Nodes:   len(ia.Sequences),
+	}
+	if len(ib.Sequences) != v.Nodes {
+		return nil, fmt.Errorf("%v nodes became %v", v.Nodes, len(ib.Sequences))
+	}
+	edges := func(idx *Index) int {
+		e := 0
+		for _, dsts := range idx.Forward {
+			e += len(dsts)
+		}
+		return e
+	}
+	v.Edges = edges(ia)
+	if e := edges(ib); e != v.Edges {
+		return nil, fmt.Errorf("%v edges became %v", v.Edges, e)
+	}
+	ka, err := a.countKeys()
+	if err != nil {
+		return nil, err
+	}
+	kb, err := b.countKeys()
+	if err != nil {
+		return nil, err
+	}
+	if ka != kb {
+		return nil, fmt.Errorf("%v keys became %v", ka, kb)
+	}
+	v.Keys = ka
+
+	nodes := ia.Nodes()
+	rng := rand.New(rand.NewSource(seed))
+	picked := rng.Perm(len(nodes))
+	if n < len(picked) {
+		picked = picked[:n]
+	}
+	sort.Ints(picked)
+	for _, i := range picked {
+		seq := ia.Sequences[nodes[i]]
+		uid, ok := ib.Lookup(seq)
+		if !ok {
+			return nil, fmt.Errorf("k-mer %s is missing", seq)
+		}
+		if !reflect.DeepEqual(newNeighbourhood(ia, nodes[i]), newNeighbourhood(ib, uid)) {
+			return nil, fmt.Errorf("neighbourhood of k-mer %s differs", seq)
+		}
+		v.Verified++
+	}
+	return v, nil
+}
+
+// countKeys counts the keys in Badger besides the uid counter and migration
+// progress, which differ between backends, and k, which older stores don't
+// record.
+func (g *Graph) countKeys() (int, error) {
+	n := 0
+	err := g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := string(it.Item().Key())
+			if key != nextUIDKey && key != kKey && !strings.HasPrefix(key, migratePrefix) {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,93 @@
+package pangenome
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func ExampleMigrate() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	src, err := openTestGraph(filepath.Join(dir, "src"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer src.Close()
+	for _, genome := range []struct{ name, seq string }{
+		{"a", "GATTACAGCGTCCATGG"},
+		{"b", "GATTACAGCTTCCATGG"},
+	} {
+		a := &Annotated{Headers: []string{">" + genome.name}, Sequences: []string{genome.seq}}
+		if _, err := src.AddAnnotated(genome.name, a, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	dst, err := openTestGraph(filepath.Join(dir, "dst"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer dst.Close()
+
+	opts := DefaultMigrateOptions
+	opts.Checkpoint = 4
+	opts.Verify = 5
+
+	// Interrupt the migration before it copies anything.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Migrate(src, dst, "badger://src", opts, ctx)
+	fmt.Println(err)
+	_, err = Migrate(src, dst, "badger://elsewhere", opts, context.Background())
+	fmt.Println(err)
+
+	report, err := Migrate(src, dst, "badger://src", opts, context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%+v\n", *report)
+	_, err = dst.GetKVStr(migrateCheckpointKey)
+	fmt.Println(err)
+	uid, _ := dst.nextUID()
+	fmt.Println(uid)
+	// Output:
+	// context canceled
+	// store holds an unfinished migration from badger://src
+	// {Resumed:true Samples:2 Nodes:18 Edges:18 Keys:46 Verified:5}
+	// Key not found
+	// 19
+}
+
+func ExampleParseBackend() {
+	for _, s := range []string{
+		"dgraph://db.example.org:9080",
+		"dgraph://localhost?badger=/data/kv",
+		"badger://graphs/ecoli",
+		"badger:///srv/ecoli",
+		"neo4j://localhost",
+	} {
+		opts, err := ParseBackend(s)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(opts.Embedded, opts.DgraphHost, opts.DgraphPort, opts.Dir)
+	}
+	// Output:
+	// false db.example.org 9080 badger
+	// false localhost 9080 /data/kv
+	// true localhost 9080 graphs/ecoli
+	// true localhost 9080 /srv/ecoli
+	// backend neo4j://localhost must start with dgraph:// or badger://
+}
@@ -13,39 +13,177 @@ import (
 	"github.com/superphy/prairiedog/kmers"
 )
 
+// Version is the prairiedog release, recorded in backups.
+const Version = "0.0.1"
+
+// DefaultK is the length of k-mers in a new store when none is given.
+const DefaultK = 11
+
 type Graph struct {
 	dg *dgo.Dgraph
 	bd *badger.DB
-	K  int
+	// K is the length of k-mers, taken from the store once it holds any.
+	K int
+	// kRecorded is set once K is stored in Badger.
+	kRecorded bool
+	// SketchK, SketchSize and SketchSeed configure the MinHash sketch
+	// stored for every genome added.
+	SketchK    int
+	SketchSize int
+	SketchSeed uint32
+	// BatchSize is the most writes committed to Badger in one transaction,
+	// or 0 to commit only when a transaction grows too big.
+	BatchSize int
+	// embedded graphs keep everything in Badger, without Dgraph.
+	embedded bool
 }
 
-// NewGraph is the main setup for backends.
+// NewGraph is the main setup for backends. They're opened as the prairiedog
+// command opens them without a config file: from DefaultOptions, overridden by
+// PRAIRIEDOG_BACKEND, PRAIRIEDOG_BADGER_DIR and PRAIRIEDOG_DGRAPH_ADDRESSES.
 func NewGraph() *Graph {
 	log.Println("Starting NewGraph().")
-	g := &Graph{
-		K: 11,
-	}
-	// Create a connection to Dgraph.
-	g.dg, _ = setupDgraph("localhost", "9080")
-	log.Println("Dgraph connected OK.")
-	// Create a connection to Badger.
-	g.bd = setupBadger()
-	log.Println("Badger connected OK.")
+	g, err := Open(envOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println("Dgraph and Badger connected OK.")
 	return g
 }
 
-// DropAll discards everything in Dgraph.
+// Options configure how Open connects to the backends.
+type Options struct {
+	Dir        string // Badger directory.
+	DgraphHost string
+	DgraphPort string
+	// DgraphAddrs are the host:port of every Dgraph server to spread
+	// requests over. If empty, DgraphHost and DgraphPort are used.
+	DgraphAddrs []string
+	// TLS secures the connections to Dgraph.
+	TLS TLSOptions
+	// SyncWrites, Truncate and ValueLogFileSize are passed on to Badger; a
+	// ValueLogFileSize of 0 keeps Badger's default.
+	SyncWrites       bool
+	Truncate         bool
+	ValueLogFileSize int64
+	// K is the length of k-mers. Open refuses a K other than the one the
+	// store was built with; 0 takes the store's, or DefaultK for a new
+	// store.
+	K          int
+	SketchK    int
+	SketchSize int
+	SketchSeed uint32
+	// BatchSize is the most writes committed to Badger in one transaction;
+	// 0 commits only when a transaction grows too big.
+	BatchSize int
+	// ReadOnly opens Badger read-only and skips Dgraph, for commands that
+	// only query the stored graph.
+	ReadOnly bool
+	// Embedded keeps the whole graph in Badger without connecting to
+	// Dgraph. Node uids are counted in Badger and edges are only kept as
+	// the contig paths they're taken from.
+	Embedded bool
+	// NoUpgrade opens a store at any schema version without upgrading it,
+	// so the pending steps can be reported.
+	NoUpgrade bool
+}
+
+// DefaultOptions are those used by NewGraph, unless overridden by the
+// environment.
+var DefaultOptions = Options{
+	Dir:        "badger",
+	DgraphHost: "localhost",
+	DgraphPort: "9080",
+	SyncWrites: badger.DefaultOptions.SyncWrites,
+	SketchK:    kmers.DefaultSketchK,
+	SketchSize: kmers.DefaultSketchSize,
+	SketchSeed: kmers.DefaultSketchSeed,
+}
+
+// Open connects to the backends with the given options, returning an error
+// rather than exiting if either can't be opened.
+func Open(opts Options) (*Graph, error) {
+	if opts.BatchSize < 0 {
+		return nil, fmt.Errorf("batch size must not be negative, got %v", opts.BatchSize)
+	}
+	if opts.K < 0 {
+		return nil, fmt.Errorf("k must not be negative, got %v", opts.K)
+	}
+	g := &Graph{
+		SketchK:    opts.SketchK,
+		SketchSize: opts.SketchSize,
+		SketchSeed: opts.SketchSeed,
+		BatchSize:  opts.BatchSize,
+		embedded:   opts.Embedded,
+	}
+	if !opts.ReadOnly && !opts.Embedded {
+		dg, err := dialDgraph(opts)
+		if err != nil {
+			return nil, err
+		}
+		g.dg = dg
+	}
+	bopts := badger.DefaultOptions
+	bopts.Dir = opts.Dir
+	bopts.ValueDir = opts.Dir
+	bopts.ReadOnly = opts.ReadOnly
+	bopts.SyncWrites = opts.SyncWrites
+	bopts.Truncate = opts.Truncate
+	if opts.ValueLogFileSize > 0 {
+		bopts.ValueLogFileSize = opts.ValueLogFileSize
+	}
+	bd, err := badger.Open(bopts)
+	if err != nil {
+		return nil, err
+	}
+	g.bd = bd
+	if !opts.NoUpgrade {
+		if err := g.checkSchema(opts.ReadOnly); err != nil {
+			g.Close()
+			return nil, err
+		}
+	}
+	k, recorded, err := g.storedK()
+	if err != nil {
+		g.Close()
+		return nil, err
+	}
+	switch {
+	case k != 0 && opts.K != 0 && k != opts.K:
+		g.Close()
+		return nil, fmt.Errorf("store was built with k=%v, not %v", k, opts.K)
+	case k != 0:
+		g.K, g.kRecorded = k, recorded
+	case opts.K != 0:
+		g.K = opts.K
+	default:
+		g.K = DefaultK
+	}
+	return g, nil
+}
+
+// DropAll discards everything in Dgraph, or everything in Badger for an
+// embedded graph.
 func (g *Graph) DropAll(contextMain context.Context) (bool, error) {
 	ctx, cancel := context.WithCancel(contextMain)
 	defer cancel()
 
+	if g.embedded {
+		if err := g.dropPrefix(""); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
 	err := g.dg.Alter(ctx, &api.Operation{DropAll: true})
 	if err != nil {
 		return false, err
 	}
 
 	// Ensure schema is still setup after dropping.
-	setupSchema(g.dg)
+	if err := setupSchema(g.dg); err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
@@ -90,6 +228,22 @@ func (g *Graph) SetKVSliceUint64(key string, value []uint64) (bool, error) {
 	return true, nil
 }
 
+// SetKVSliceStr sets the key: value pair in Badger for slices of strings.
+func (g *Graph) SetKVSliceStr(key string, value []string) (bool, error) {
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	err = g.bd.Update(func(txn *badger.Txn) error {
+		err := txn.Set([]byte(key), buf)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // GetKVInt get the key: value pair in Badger.
 func (g *Graph) GetKVInt(key string) (int, error) {
 	var valCopy []byte
@@ -176,17 +330,51 @@ func (g *Graph) GetKVSliceUint64(key string) ([]uint64, error) {
 	return sl, nil
 }
 
+// GetKVSliceStr gets the key: value pair in Badger.
+func (g *Graph) GetKVSliceStr(key string) ([]string, error) {
+	var valCopy []byte
+	err := g.bd.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		err = item.Value(func(val []byte) error {
+			// This func with val would only be called if item.Value encounters no error.
+			// Copying or parsing val is valid.
+			valCopy = append([]byte{}, val...)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var sl []string
+	err = json.Unmarshal(valCopy, &sl)
+	if err != nil {
+		return nil, err
+	}
+	return sl, nil
+}
+
 func (g *Graph) CreateNode(seq string, contextMain context.Context) (uint64, error) {
 	ctx, cancel := context.WithCancel(contextMain)
 	defer cancel()
 
+	if g.embedded {
+		return g.nextUID()
+	}
+
 	node := KmerNode{
 		Sequence: seq,
 	}
 
 	nb, err := json.Marshal(node)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
 	mu := &api.Mutation{
@@ -200,59 +388,98 @@ func (g *Graph) CreateNode(seq string, contextMain context.Context) (uint64, err
 
 	assigned, err := txn.Mutate(ctx, mu)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
 	// Return the UID assigned by Dgraph.
-	uid, err := strconv.ParseUint(assigned.Uids["blank-0"][2:], 16, 64)
-	if err != nil {
-		log.Fatal(err)
+	blank, ok := assigned.Uids["blank-0"]
+	if !ok || len(blank) < 2 {
+		return 0, fmt.Errorf("dgraph assigned no uid for %s", seq)
 	}
-	return uid, err
+	return strconv.ParseUint(blank[2:], 16, 64)
 }
 
-func (g *Graph) GetNode(seq string, contextMain context.Context) (uint64, bool) {
-	ctx, cancel := context.WithCancel(contextMain)
-	defer cancel()
-
-	txn := g.dg.NewTxn()
-	defer txn.Discard(ctx)
-
-	q := fmt.Sprintf(`
-		{
-			q(func: eq(Sequence, %s)) {
-				uid
-			}
+// UpsertNode returns the uid of the node for seq, creating the node only if the
+// k-mer isn't already in the graph. The k-mer: uid mapping is kept in Badger so
+// lookups don't need a Dgraph query. A k-mer is noted as pending while its
+// Dgraph node is created, so reconcileNodes can find a node that Badger never
+// recorded.
+func (g *Graph) UpsertNode(seq string, contextMain context.Context) (uint64, error) {
+	var uid uint64
+	err := g.bd.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(kmerPrefix + seq))
+		if err != nil {
+			return err
 		}
-	`, seq)
-	resp, err := txn.Query(ctx, q)
-	if err != nil {
-		log.Fatal(err)
+		return item.Value(func(val []byte) error {
+			uid, err = strconv.ParseUint(string(val), 10, 64)
+			return err
+		})
+	})
+	if err == nil {
+		return uid, nil
+	}
+	if err != badger.ErrKeyNotFound {
+		return 0, err
 	}
 
-	var decode struct {
-		All []struct {
-			Uid string
+	if !g.embedded {
+		if _, err := g.SetKVStr(pendingPrefix+seq, ""); err != nil {
+			return 0, err
 		}
 	}
-	log.Println(resp.GetJson())
-	if err := json.Unmarshal(resp.GetJson(), &decode); err != nil {
-		log.Fatal(err)
-	}
-	if len(decode.All) == 0 {
-		return 0, false
+	uid, err = g.CreateNode(seq, contextMain)
+	if err != nil {
+		return 0, err
 	}
-	i, err := strconv.ParseUint(decode.All[0].Uid, 16, 64)
+	err = g.bd.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(kmerPrefix+seq), []byte(strconv.FormatUint(uid, 10))); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(nodeKey(uid)), []byte(seq)); err != nil {
+			return err
+		}
+		if g.embedded {
+			return nil
+		}
+		return txn.Delete([]byte(pendingPrefix + seq))
+	})
 	if err != nil {
-		return 0, false
+		return 0, err
 	}
-	return i, true
+	return uid, nil
+}
+
+// GetNode returns the uid of the node for seq, and whether it was found.
+func (g *Graph) GetNode(seq string, contextMain context.Context) (uint64, bool, error) {
+	ctx, cancel := context.WithCancel(contextMain)
+	defer cancel()
+
+	if g.embedded {
+		found, err := g.LookupKmers([]string{seq})
+		if err != nil {
+			return 0, false, err
+		}
+		uid, ok := found[seq]
+		return uid, ok, nil
+	}
+
+	uids, err := g.dgraphNodes(seq, ctx)
+	if err != nil || len(uids) == 0 {
+		return 0, false, err
+	}
+	return uids[0], true, nil
 }
 
 func (g *Graph) CreateEdge(src uint64, dst uint64, contextMain context.Context) (*api.Assigned, error) {
 	ctx, cancel := context.WithCancel(contextMain)
 	defer cancel()
 
+	// Embedded graphs rebuild edges from the contig paths.
+	if g.embedded {
+		return &api.Assigned{}, nil
+	}
+
 	srcNode := KmerNode{
 		UID: src,
 		ForwardNodes: []KmerNode{{
@@ -262,7 +489,7 @@ func (g *Graph) CreateEdge(src uint64, dst uint64, contextMain context.Context)
 
 	nb, err := json.Marshal(srcNode)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	mu := &api.Mutation{
@@ -274,41 +501,63 @@ func (g *Graph) CreateEdge(src uint64, dst uint64, contextMain context.Context)
 	txn := g.dg.NewTxn()
 	defer txn.Discard(ctx)
 
-	assigned, err := txn.Mutate(ctx, mu)
-	if err != nil {
-		log.Fatal(err)
+	return txn.Mutate(ctx, mu)
+}
+
+// CreateAll Nodes+Edges for all kmers in km, storing contig paths under the
+// sample name. Cancelling ctx stops it before
+// the next k-mer, returning the context's error. A contig's path is only
+// stored once all of its nodes and edges are, and nodes are only recorded in
+// Badger once created, so an interrupted genome can simply be added again.
+func (g *Graph) CreateAll(name string, km *kmers.Kmers, contextMain context.Context) (bool, error) {
+	if err := g.createAll(name, km, 0, nil, contextMain); err != nil {
+		return false, err
 	}
-	return assigned, err
+	return true, nil
 }
 
-// CreateAll Nodes+Edges for all kmers in km.
-func (g *Graph) CreateAll(km *kmers.Kmers, contextMain context.Context) (bool, error) {
+// createAll is CreateAll, except the first skip contigs are only read, so
+// they're still sketched, and stored is called with the number of contigs
+// done once each path is stored.
+func (g *Graph) createAll(name string, km *kmers.Kmers, skip int, stored func(contigs int) error, contextMain context.Context) error {
+	if km.K != g.K {
+		return fmt.Errorf("k-mers of %s are %v long, not k=%v", name, km.K, g.K)
+	}
+	if err := g.recordK(); err != nil {
+		return err
+	}
 	ctx, cancel := context.WithCancel(contextMain)
 	defer cancel()
 
 	var seq1, seq2, header1 string
+	contig := 0
 	// Initial Kmer.
 	header1, seq1 = km.Next()
 	// If there exists any kmers left in the genome.
 	for km.HasNext() {
 		var sl []uint64
+		write := contig >= skip
 		// If there exists any kmers left in the particular contig.
 		for km.ContigHasNext() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			_, seq2 = km.Next()
+			if !write {
+				continue
+			}
 
-			uid1, err := g.CreateNode(seq1, ctx)
+			uid1, err := g.UpsertNode(seq1, ctx)
 			if err != nil {
-				log.Fatal(err)
-				return false, err
+				return err
 			}
 
 			// Always append the first node.
 			sl = append(sl, uid1)
 
-			uid2, err := g.CreateNode(seq2, ctx)
+			uid2, err := g.UpsertNode(seq2, ctx)
 			if err != nil {
-				log.Fatal(err)
-				return false, err
+				return err
 			}
 
 			// If on last kmer in a contig, append the second node.
@@ -318,38 +567,113 @@ func (g *Graph) CreateAll(km *kmers.Kmers, contextMain context.Context) (bool, e
 
 			_, err = g.CreateEdge(uid1, uid2, ctx)
 			if err != nil {
-				log.Fatal(err)
-				return false, err
+				return err
 			}
 			seq1 = seq2
 
 		}
-		// Store the completed path for the contig.
-		g.SetKVSliceUint64(header1, sl)
+		contig++
+		if write {
+			// Store the completed path for the contig.
+			if _, err := g.SetKVSliceUint64(pathKey(name, header1), sl); err != nil {
+				return err
+			}
+			if stored != nil {
+				if err := stored(contig); err != nil {
+					return err
+				}
+			}
+		}
 		// Grab next sequence.
 		header1, seq1 = km.Next()
 	}
-	return true, nil
+	return nil
 }
 
-func Run() {
-	// Databases.
-	g := NewGraph()
-	defer g.Close()
-	contextMain, cancel := context.WithCancel(context.Background())
+// AddGenome creates all Nodes+Edges for km and registers the contigs under
+// the sample name, storing a MinHash sketch of its k-mers alongside. If the
+// graph is compacted, unitigs split by the new sample are recompacted.
+//
+// The sample is registered last, once everything it refers to is stored. If
+// ctx is cancelled before then, nothing names the sample and it can be added
+// again; once it's registered, AddGenome finishes regardless. It's refused
+// while Build is interrupted creating the nodes of a sample, as resuming that
+// sample would remove nodes created since.
+func (g *Graph) AddGenome(name string, km *kmers.Kmers, contextMain context.Context) (bool, error) {
+	ctx, cancel := context.WithCancel(contextMain)
 	defer cancel()
 
-	// Load a genome file.
-	km := kmers.New("testdata/ECI-2523.fsa")
+	exists, err := g.HasSample(name)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, fmt.Errorf("sample %s already exists", name)
+	}
+	if len(km.Sequences) == 0 {
+		return false, fmt.Errorf("sample %s has no sequences", name)
+	}
+	if err := g.checkPartialBuilds(); err != nil {
+		return false, err
+	}
 
-	h, seq := km.Next()
-	fmt.Println(h, seq)
+	headers, err := g.addNodes(name, km, 0, nil, ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if err := g.AddSample(name, headers); err != nil {
+		return false, err
+	}
+	if err := g.updateCompacted(name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	g.CreateNode(seq, contextMain)
+// addNodes creates every node, edge and contig path of a genome, with skip
+// and stored passed on to createAll, and stores its sketch. Nodes left
+// pending by an interrupted add are reconciled first. It returns the
+// headers of the contigs long enough to have a path.
+func (g *Graph) addNodes(name string, km *kmers.Kmers, skip int, stored func(int) error, ctx context.Context) ([]string, error) {
+	if km.Sketch == nil {
+		km.Sketch = kmers.NewSketch(g.SketchK, g.SketchSize, g.SketchSeed)
+	}
+	if !g.embedded {
+		if err := g.reconcileNodes(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.createAll(name, km, skip, stored, ctx); err != nil {
+		return nil, err
+	}
+	if err := g.SetSketch(name, km.Sketch); err != nil {
+		return nil, err
+	}
+
+	// Only keep the contigs which were long enough to store a path.
+	var headers []string
+	for _, header := range km.Headers {
+		if _, err := g.ContigPath(name, header); err == nil {
+			headers = append(headers, header)
+		}
+	}
+	return headers, nil
+}
 
-	// TODO: remove
-	_ = g
-	_ = km
+// updateCompacted keeps unitigs valid for a new sample if the graph has
+// already been compacted.
+func (g *Graph) updateCompacted(name string) error {
+	compacted, err := g.Compacted()
+	if err != nil {
+		return err
+	}
+	if compacted {
+		return g.updateUnitigs(name)
+	}
+	return nil
 }
 
 // Close handles teardown.
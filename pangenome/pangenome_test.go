@@ -0,0 +1,187 @@
+package pangenome
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dgraph-io/badger"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+func ExampleGraph_AddGenome() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	g.BatchSize = 2
+
+	// An interrupted genome leaves no sample behind, so it can be added again.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	km := kmers.NewFromSequences([]string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	km.K = g.K
+	_, err = g.AddGenome("a", km, ctx)
+	fmt.Println(err)
+	exists, _ := g.HasSample("a")
+	fmt.Println(exists)
+
+	km = kmers.NewFromSequences([]string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	km.K = g.K
+	_, err = g.AddGenome("a", km, context.Background())
+	fmt.Println(err)
+	path, _ := g.ContigPath("a", ">a")
+	fmt.Println(path)
+
+	_, err = g.AddGenome("b", kmers.NewFromSequences(nil, nil), context.Background())
+	fmt.Println(err)
+
+	// Contigs no longer than k are left out, even at the end.
+	km = kmers.NewFromSequences([]string{">c1", ">c2"}, []string{"GATTACAGCGTCCATGG", "GAT"})
+	km.K = g.K
+	_, err = g.AddGenome("c", km, context.Background())
+	fmt.Println(err)
+	fmt.Println(g.SampleContigs("c"))
+	// Output:
+	// context canceled
+	// false
+	// <nil>
+	// [1 2 3 4 5 6 7 8 9 10 11 12 13]
+	// sample b has no sequences
+	// <nil>
+	// [>c1] <nil>
+}
+
+func ExampleOpen_k() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	opts := DefaultOptions
+	opts.Dir = dir
+	opts.Embedded = true
+	g, err := Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	// An empty store isn't held to the k it was opened with.
+	fmt.Println(g.K)
+	g.Close()
+
+	opts.K = 5
+	g, err = Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	km := kmers.NewFromSequences([]string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	km.K = 7
+	_, err = g.AddGenome("a", km, context.Background())
+	fmt.Println(err)
+	km = kmers.NewFromSequences([]string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	km.K = g.K
+	_, err = g.AddGenome("a", km, context.Background())
+	fmt.Println(err)
+	g.Close()
+
+	opts.K = 7
+	_, err = Open(opts)
+	fmt.Println(err)
+
+	// Stores from before k was recorded take it from their k-mers.
+	opts.K = 0
+	g, err = Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.bd.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(kKey))
+	})
+	g.Close()
+	g, err = Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	fmt.Println(g.K)
+	// Output:
+	// 11
+	// k-mers of a are 7 long, not k=5
+	// <nil>
+	// store was built with k=5, not 7
+	// 5
+}
+
+func ExampleGraph_ContigPath() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+
+	// Assemblers name contigs alike in every genome.
+	for _, genome := range []struct{ name, seq string }{
+		{"a", "GATTACAGCG"},
+		{"b", "CCCGGGTTTA"},
+	} {
+		km := kmers.NewFromSequences([]string{">contig1"}, []string{genome.seq})
+		km.K = g.K
+		if _, err := g.AddGenome(genome.name, km, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	a, _ := g.ContigPath("a", ">contig1")
+	b, _ := g.ContigPath("b", ">contig1")
+	fmt.Println(a, b)
+
+	idx, err := g.LoadIndex()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(idx.Spell(idx.Path(0, ">contig1")), idx.Spell(idx.Path(1, ">contig1")))
+
+	var buf bytes.Buffer
+	if err := WritePDG(&buf, idx); err != nil {
+		fmt.Println(err)
+		return
+	}
+	p, err := ReadPDG(buf.Bytes())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	a, _ = p.ContigPath("a", ">contig1")
+	b, _ = p.ContigPath("b", ">contig1")
+	fmt.Println(a, b)
+	// Output:
+	// [1 2 3 4 5 6] [7 8 9 10 11 12]
+	// GATTACAGCG CCCGGGTTTA
+	// [1 2 3 4 5 6] [7 8 9 10 11 12]
+}
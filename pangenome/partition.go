@@ -0,0 +1,137 @@
+package pangenome
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Partition is the class of a node or unitig by how many samples contain it.
+type Partition int
+
+// Partitions, from most to least shared.
+const (
+	Core Partition = iota
+	SoftCore
+	Shell
+	Cloud
+)
+
+// Partitions lists every partition in order.
+var Partitions = []Partition{Core, SoftCore, Shell, Cloud}
+
+func (p Partition) String() string {
+	switch p {
+	case Core:
+		return "core"
+	case SoftCore:
+		return "soft-core"
+	case Shell:
+		return "shell"
+	case Cloud:
+		return "cloud"
+	}
+	return "unknown"
+}
+
+// Thresholds are the lower bounds, as a fraction of samples, of the core,
+// soft-core and shell partitions. Anything below Shell is cloud.
+type Thresholds struct {
+	Core     float64
+	SoftCore float64
+	Shell    float64
+}
+
+// DefaultThresholds are the same as Roary's.
+var DefaultThresholds = Thresholds{
+	Core:     0.99,
+	SoftCore: 0.95,
+	Shell:    0.15,
+}
+
+// Validate checks the thresholds are in (0, 1] and decreasing.
+func (t Thresholds) Validate() error {
+	if t.Core > 1 || t.Core < t.SoftCore || t.SoftCore < t.Shell || t.Shell <= 0 {
+		return fmt.Errorf("thresholds must satisfy 1 >= core (%v) >= soft-core (%v) >= shell (%v) > 0", t.Core, t.SoftCore, t.Shell)
+	}
+	return nil
+}
+
+// Classify returns the partition of something found in n of total samples.
+func (t Thresholds) Classify(n int, total int) Partition {
+	if total == 0 {
+		return Cloud
+	}
+	f := float64(n) / float64(total)
+	switch {
+	case f >= t.Core:
+		return Core
+	case f >= t.SoftCore:
+		return SoftCore
+	case f >= t.Shell:
+		return Shell
+	}
+	return Cloud
+}
+
+// NodeUnitigs returns every k-mer node as a unitig of its own, with the node
+// uid as the id, so nodes can be treated like a compacted graph.
+func (idx *Index) NodeUnitigs() []*Unitig {
+	unitigs := make([]*Unitig, 0, len(idx.Sequences))
+	for uid := range idx.Sequences {
+		u := idx.newUnitig([]uint64{uid})
+		u.ID = uid
+		unitigs = append(unitigs, u)
+	}
+	sort.Slice(unitigs, func(i, j int) bool { return unitigs[i].ID < unitigs[j].ID })
+	return unitigs
+}
+
+// Partition splits unitigs by the fraction of the samples of idx containing
+// them.
+func (idx *Index) Partition(unitigs []*Unitig, t Thresholds) map[Partition][]*Unitig {
+	parts := make(map[Partition][]*Unitig)
+	for _, u := range unitigs {
+		p := t.Classify(u.Colours.Count(), len(idx.Samples))
+		parts[p] = append(parts[p], u)
+	}
+	return parts
+}
+
+// WritePartitionSummary writes a summary table in the style of Roary's
+// summary_statistics.txt.
+func WritePartitionSummary(w io.Writer, parts map[Partition][]*Unitig, t Thresholds) error {
+	bounds := []struct {
+		name     string
+		low, top float64
+		upper    string
+	}{
+		{"Core", t.Core, 1, "<="},
+		{"Soft core", t.SoftCore, t.Core, "<"},
+		{"Shell", t.Shell, t.SoftCore, "<"},
+		{"Cloud", 0, t.Shell, "<"},
+	}
+	total := 0
+	for i, p := range Partitions {
+		b := bounds[i]
+		n := len(parts[p])
+		total += n
+		_, err := fmt.Fprintf(w, "%s\t(%.4g%% <= strains %s %.4g%%)\t%v\n", b.name, b.low*100, b.upper, b.top*100, n)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "Total\t(0%% <= strains <= 100%%)\t%v\n", total)
+	return err
+}
+
+// WriteFasta writes the sequence of each unitig, one record per unitig.
+func WriteFasta(w io.Writer, unitigs []*Unitig) error {
+	for _, u := range unitigs {
+		_, err := fmt.Fprintf(w, ">%v samples=%v\n%s\n", u.ID, u.Colours.Count(), u.Sequence)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
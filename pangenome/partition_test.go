@@ -0,0 +1,26 @@
+package pangenome
+
+import (
+	"os"
+)
+
+func ExampleWritePartitionSummary() {
+	idx := newTestIndex(
+		[]uint64{1, 2, 3, 4},
+		[]uint64{5, 2, 3},
+		[]uint64{6, 7, 2, 3},
+	)
+	seqs := map[uint64]string{1: "ACG", 2: "CGT", 3: "GTA", 4: "TAC", 5: "TCG", 6: "AAA", 7: "AAC"}
+	for uid, seq := range seqs {
+		idx.Sequences[uid] = seq
+	}
+	t := Thresholds{Core: 1, SoftCore: 0.6, Shell: 0.5}
+	parts := idx.Partition(idx.NodeUnitigs(), t)
+	WritePartitionSummary(os.Stdout, parts, t)
+	// Output:
+	// Core	(100% <= strains <= 100%)	2
+	// Soft core	(60% <= strains < 100%)	0
+	// Shell	(50% <= strains < 60%)	0
+	// Cloud	(0% <= strains < 50%)	5
+	// Total	(0% <= strains <= 100%)	7
+}
@@ -0,0 +1,235 @@
+package pangenome
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Path is a walk between two k-mers. Probabilities[i] is the probability of
+// taking the edge from Nodes[i] to Nodes[i+1], which is its weight relative to
+// every edge leaving Nodes[i]. Probability is their product.
+type Path struct {
+	Nodes         []uint64  `json:"nodes"`
+	Sequence      string    `json:"sequence"`
+	Probabilities []float64 `json:"probabilities"`
+	Probability   float64   `json:"probability"`
+}
+
+// Transition returns the probability of stepping from src to dst.
+func (idx *Index) Transition(src uint64, dst uint64) float64 {
+	total := 0
+	for _, w := range idx.Forward[src] {
+		total += w
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(idx.Forward[src][dst]) / float64(total)
+}
+
+func (idx *Index) newPath(nodes []uint64) *Path {
+	p := &Path{
+		Nodes:       nodes,
+		Sequence:    idx.Spell(nodes),
+		Probability: 1,
+	}
+	for i := 1; i < len(nodes); i++ {
+		t := idx.Transition(nodes[i-1], nodes[i])
+		p.Probabilities = append(p.Probabilities, t)
+		p.Probability *= t
+	}
+	return p
+}
+
+// lookupPair resolves the two k-mers of a path query.
+func (idx *Index) lookupPair(from string, to string) (uint64, uint64, error) {
+	src, ok := idx.Lookup(from)
+	if !ok {
+		return 0, 0, fmt.Errorf("k-mer %s is not in the graph", from)
+	}
+	dst, ok := idx.Lookup(to)
+	if !ok {
+		return 0, 0, fmt.Errorf("k-mer %s is not in the graph", to)
+	}
+	return src, dst, nil
+}
+
+// ShortestPath returns the path with the fewest edges between two k-mers, or
+// nil if there is none.
+func (idx *Index) ShortestPath(from string, to string) (*Path, error) {
+	src, dst, err := idx.lookupPair(from, to)
+	if err != nil {
+		return nil, err
+	}
+	nodes := idx.search(src, dst, func(uint64, uint64) float64 { return 1 }, nil, nil)
+	if nodes == nil {
+		return nil, nil
+	}
+	return idx.newPath(nodes), nil
+}
+
+// MostProbablePath returns the path with the highest product of transition
+// probabilities between two k-mers, or nil if there is none.
+func (idx *Index) MostProbablePath(from string, to string) (*Path, error) {
+	paths, err := idx.KBestPaths(from, to, 1)
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	return paths[0], nil
+}
+
+// KBestPaths returns up to k loopless paths between two k-mers, most probable
+// first, using Yen's algorithm.
+func (idx *Index) KBestPaths(from string, to string, k int) ([]*Path, error) {
+	src, dst, err := idx.lookupPair(from, to)
+	if err != nil {
+		return nil, err
+	}
+	cost := func(u uint64, v uint64) float64 {
+		return -math.Log(idx.Transition(u, v))
+	}
+
+	first := idx.search(src, dst, cost, nil, nil)
+	if first == nil {
+		return nil, nil
+	}
+	best := [][]uint64{first}
+	var candidates [][]uint64
+	for len(best) < k {
+		last := best[len(best)-1]
+		for i := 0; i < len(last)-1; i++ {
+			spur := last[i]
+			root := last[:i+1]
+
+			// Remove the next edge of every known path sharing this root, and
+			// the root itself, so the spur path is new and loopless.
+			bannedEdges := make(map[[2]uint64]bool)
+			for _, p := range best {
+				if len(p) > i+1 && equalPaths(p[:i+1], root) {
+					bannedEdges[[2]uint64{p[i], p[i+1]}] = true
+				}
+			}
+			bannedNodes := make(map[uint64]bool)
+			for _, uid := range root[:len(root)-1] {
+				bannedNodes[uid] = true
+			}
+
+			spurPath := idx.search(spur, dst, cost, bannedNodes, bannedEdges)
+			if spurPath == nil {
+				continue
+			}
+			candidate := append(append([]uint64{}, root[:len(root)-1]...), spurPath...)
+			if !containsPath(candidates, candidate) && !containsPath(best, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return pathCost(candidates[i], cost) < pathCost(candidates[j], cost)
+		})
+		best = append(best, candidates[0])
+		candidates = candidates[1:]
+	}
+
+	paths := make([]*Path, len(best))
+	for i, nodes := range best {
+		paths[i] = idx.newPath(nodes)
+	}
+	return paths, nil
+}
+
+func pathCost(nodes []uint64, cost func(uint64, uint64) float64) float64 {
+	c := 0.0
+	for i := 1; i < len(nodes); i++ {
+		c += cost(nodes[i-1], nodes[i])
+	}
+	return c
+}
+
+func equalPaths(a []uint64, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths [][]uint64, p []uint64) bool {
+	for _, q := range paths {
+		if equalPaths(p, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// search is Dijkstra's algorithm from src to dst, skipping banned nodes and
+// edges. It returns nil if dst can't be reached.
+func (idx *Index) search(src uint64, dst uint64, cost func(uint64, uint64) float64, bannedNodes map[uint64]bool, bannedEdges map[[2]uint64]bool) []uint64 {
+	dist := map[uint64]float64{src: 0}
+	prev := make(map[uint64]uint64)
+	done := make(map[uint64]bool)
+	q := &queue{{uid: src}}
+	for q.Len() > 0 {
+		item := heap.Pop(q).(queueItem)
+		u := item.uid
+		if done[u] {
+			continue
+		}
+		done[u] = true
+		if u == dst {
+			break
+		}
+		succ, _ := idx.successors(u)
+		for _, v := range succ {
+			if bannedNodes[v] || bannedEdges[[2]uint64{u, v}] || done[v] {
+				continue
+			}
+			d := dist[u] + cost(u, v)
+			if old, ok := dist[v]; !ok || d < old {
+				dist[v] = d
+				prev[v] = u
+				heap.Push(q, queueItem{uid: v, dist: d})
+			}
+		}
+	}
+	if !done[dst] {
+		return nil
+	}
+	nodes := []uint64{dst}
+	for cur := dst; cur != src; {
+		cur = prev[cur]
+		nodes = append(nodes, cur)
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes
+}
+
+type queueItem struct {
+	uid  uint64
+	dist float64
+}
+
+// queue is a min-heap of nodes by distance.
+type queue []queueItem
+
+func (q queue) Len() int            { return len(q) }
+func (q queue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,23 @@
+package pangenome
+
+import (
+	"fmt"
+)
+
+func ExampleIndex_KBestPaths() {
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	idx.AddGenome("b", []string{">b"}, []string{"GATTACAGCGTCCATGG"})
+	idx.AddGenome("c", []string{">c"}, []string{"GATTACAGCTTCCATGG"})
+
+	shortest, _ := idx.ShortestPath("GATTA", "CATGG")
+	fmt.Println(len(shortest.Nodes))
+	paths, _ := idx.KBestPaths("GATTA", "CATGG", 3)
+	for _, p := range paths {
+		fmt.Printf("%s %.2f\n", p.Sequence, p.Probability)
+	}
+	// Output:
+	// 13
+	// GATTACAGCGTCCATGG 0.67
+	// GATTACAGCTTCCATGG 0.33
+}
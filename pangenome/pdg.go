@@ -0,0 +1,652 @@
+package pangenome
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+)
+
+// A PDG file is a whole pangenome in one file, laid out so it can be
+// memory-mapped and queried in place. All integers are little-endian. The
+// header is:
+//
+//	magic    [8]byte  "PDGRAPH\n"
+//	version  uint32
+//	k        uint32
+//	flags    uint32
+//	samples  uint32
+//	nodes    uint64
+//	packed   uint64   nodes whose k-mers are pure ACGT.
+//	edges    uint64
+//	sections [10]struct{ offset, length uint64 }
+//
+// followed by the sections, each 8-byte aligned, in the order below. Nodes
+// are numbered by their place in the k-mer arrays: the packed k-mers first,
+// sorted, then the rest. Edges and paths refer to nodes by number, and the
+// uids array maps them back to the uids they had in the graph.
+const (
+	pdgSamples = iota // per sample: name, contig count, then per contig its header, path offset and length. Strings are a uint32 length and bytes.
+	pdgKmers          // packed: uint64 k-mers, 2 bits a base, sorted.
+	pdgOther          // nodes - packed: k-byte k-mers with other bases, sorted.
+	pdgUIDs           // nodes: uint64 uids.
+	pdgByUID          // nodes: uint32 node numbers sorted by uid.
+	pdgOffsets        // nodes + 1: uint64 start of each node's edges.
+	pdgTargets        // edges: uint32 destination nodes.
+	pdgWeights        // edges: uint32 weights.
+	pdgColours        // nodes * words: uint64 colour bitmaps, words = ceil(samples / 64).
+	pdgPaths          // uint32 node numbers of every contig path.
+	pdgSections
+)
+
+const (
+	pdgMagic      = "PDGRAPH\n"
+	pdgHeaderSize = 48 + 16*pdgSections
+
+	// PDGVersion is the version of the PDG format written by WritePDG.
+	PDGVersion = 1
+	// PDGCanonical is the flag for files of canonical k-mers, which aren't
+	// written yet.
+	PDGCanonical = 1 << 0
+)
+
+// Querier is the read-only query interface shared by a Graph and an opened
+// PDG file.
+type Querier interface {
+	Samples() ([]string, error)
+	HasSample(name string) (bool, error)
+	SampleContigs(name string) ([]string, error)
+	ContigPath(sample string, header string) ([]uint64, error)
+	LookupKmers(seqs []string) (map[string]uint64, error)
+	Locate(seq string) (*Location, error)
+	LoadIndex() (*Index, error)
+	Stats() (*Stats, error)
+	SampleHaplotype(start string, end string, rng *rand.Rand) (string, error)
+	Close()
+}
+
+var (
+	_ Querier = (*Graph)(nil)
+	_ Querier = (*PDG)(nil)
+)
+
+// packKmer packs a k-mer of up to 32 bases into 2 bits a base, returning
+// false if it has anything but ACGT.
+func packKmer(seq string) (uint64, bool) {
+	if len(seq) > 32 {
+		return 0, false
+	}
+	var v uint64
+	for i := 0; i < len(seq); i++ {
+		var b uint64
+		switch seq[i] {
+		case 'A':
+			b = 0
+		case 'C':
+			b = 1
+		case 'G':
+			b = 2
+		case 'T':
+			b = 3
+		default:
+			return 0, false
+		}
+		v = v<<2 | b
+	}
+	return v, true
+}
+
+// unpackKmer spells a packed k-mer of k bases.
+func unpackKmer(v uint64, k int) string {
+	seq := make([]byte, k)
+	for i := k - 1; i >= 0; i-- {
+		seq[i] = bases[v&3]
+		v >>= 2
+	}
+	return string(seq)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	return append(appendUint32(b, uint32(len(s))), s...)
+}
+
+// WritePDG writes the index as a PDG file. K can be at most 32, and every
+// node on a path must have a sequence.
+func WritePDG(w io.Writer, idx *Index) error {
+	if idx.K < 1 || idx.K > 32 {
+		return fmt.Errorf("k=%v can't be packed, it must be between 1 and 32", idx.K)
+	}
+	var packed, other []uint64
+	values := make(map[uint64]uint64)
+	for uid, seq := range idx.Sequences {
+		if len(seq) != idx.K {
+			return fmt.Errorf("node %v is %v bases long, not k=%v", uid, len(seq), idx.K)
+		}
+		if v, ok := packKmer(seq); ok {
+			values[uid] = v
+			packed = append(packed, uid)
+		} else {
+			other = append(other, uid)
+		}
+	}
+	sort.Slice(packed, func(i, j int) bool { return values[packed[i]] < values[packed[j]] })
+	sort.Slice(other, func(i, j int) bool { return idx.Sequences[other[i]] < idx.Sequences[other[j]] })
+	order := append(append([]uint64{}, packed...), other...)
+	nodes := make(map[uint64]uint32, len(order))
+	for i, uid := range order {
+		nodes[uid] = uint32(i)
+	}
+
+	var sections [pdgSections][]byte
+	var paths []byte
+	npaths := uint64(0)
+	s := appendUint32(nil, uint32(len(idx.Samples)))
+	for i, sample := range idx.Samples {
+		s = appendString(s, sample)
+		var contigs []string
+		if i < len(idx.Contigs) {
+			contigs = idx.Contigs[i]
+		}
+		s = appendUint32(s, uint32(len(contigs)))
+		for _, header := range contigs {
+			path := idx.Path(i, header)
+			s = appendString(s, header)
+			s = appendUint64(s, npaths)
+			s = appendUint64(s, uint64(len(path)))
+			for _, uid := range path {
+				n, ok := nodes[uid]
+				if !ok {
+					return fmt.Errorf("contig %s visits node %v, which has no sequence", header, uid)
+				}
+				paths = appendUint32(paths, n)
+			}
+			npaths += uint64(len(path))
+		}
+	}
+	sections[pdgSamples] = s
+	sections[pdgPaths] = paths
+
+	for _, uid := range packed {
+		sections[pdgKmers] = appendUint64(sections[pdgKmers], values[uid])
+	}
+	for _, uid := range other {
+		sections[pdgOther] = append(sections[pdgOther], idx.Sequences[uid]...)
+	}
+	for _, uid := range order {
+		sections[pdgUIDs] = appendUint64(sections[pdgUIDs], uid)
+	}
+	byUID := append([]uint64{}, order...)
+	sort.Slice(byUID, func(i, j int) bool { return byUID[i] < byUID[j] })
+	for _, uid := range byUID {
+		sections[pdgByUID] = appendUint32(sections[pdgByUID], nodes[uid])
+	}
+
+	words := (len(idx.Samples) + 63) / 64
+	edges := uint64(0)
+	for _, uid := range order {
+		sections[pdgOffsets] = appendUint64(sections[pdgOffsets], edges)
+		dsts := make([]uint32, 0, len(idx.Forward[uid]))
+		for dst := range idx.Forward[uid] {
+			n, ok := nodes[dst]
+			if !ok {
+				return fmt.Errorf("edge %v-%v leads to a node with no sequence", uid, dst)
+			}
+			dsts = append(dsts, n)
+		}
+		sort.Slice(dsts, func(i, j int) bool { return dsts[i] < dsts[j] })
+		for _, n := range dsts {
+			sections[pdgTargets] = appendUint32(sections[pdgTargets], n)
+			sections[pdgWeights] = appendUint32(sections[pdgWeights], uint32(idx.Forward[uid][order[n]]))
+		}
+		edges += uint64(len(dsts))
+
+		c := idx.Colours[uid]
+		for i := 0; i < words; i++ {
+			var word uint64
+			if i < len(c) {
+				word = c[i]
+			}
+			sections[pdgColours] = appendUint64(sections[pdgColours], word)
+		}
+	}
+	sections[pdgOffsets] = appendUint64(sections[pdgOffsets], edges)
+
+	header := []byte(pdgMagic)
+	header = appendUint32(header, PDGVersion)
+	header = appendUint32(header, uint32(idx.K))
+	header = appendUint32(header, 0)
+	header = appendUint32(header, uint32(len(idx.Samples)))
+	header = appendUint64(header, uint64(len(order)))
+	header = appendUint64(header, uint64(len(packed)))
+	header = appendUint64(header, edges)
+	offset := uint64(pdgHeaderSize)
+	for _, section := range sections {
+		header = appendUint64(header, offset)
+		header = appendUint64(header, uint64(len(section)))
+		offset += pdgAlign(uint64(len(section)))
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	var pad [8]byte
+	for _, section := range sections {
+		if _, err := w.Write(section); err != nil {
+			return err
+		}
+		if _, err := w.Write(pad[:pdgAlign(uint64(len(section)))-uint64(len(section))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pdgAlign rounds n up to a multiple of 8.
+func pdgAlign(n uint64) uint64 {
+	return (n + 7) &^ 7
+}
+
+// PDG is an opened PDG file. Queries read the file in place, apart from
+// LoadIndex and the queries built on it.
+type PDG struct {
+	K        int
+	Flags    uint32
+	data     []byte
+	close    func() error
+	nodes    int
+	packed   int
+	edges    int
+	words    int
+	sections [pdgSections][]byte
+	samples  []string
+	contigs  [][]string
+	paths    []map[string][2]uint64 // header: path offset and length, per sample.
+}
+
+// OpenPDG memory-maps a PDG file, where the platform allows, and checks its
+// header.
+func OpenPDG(path string) (*PDG, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ReadPDG(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	p.close = unmap
+	return p, nil
+}
+
+// ReadPDG reads a PDG file already in memory, without copying it.
+func ReadPDG(data []byte) (*PDG, error) {
+	le := binary.LittleEndian
+	if len(data) < pdgHeaderSize || string(data[:8]) != pdgMagic {
+		return nil, fmt.Errorf("not a PDG file")
+	}
+	if v := le.Uint32(data[8:]); v > PDGVersion {
+		return nil, fmt.Errorf("PDG version %v is newer than %v", v, PDGVersion)
+	}
+	p := &PDG{
+		K:      int(le.Uint32(data[12:])),
+		Flags:  le.Uint32(data[16:]),
+		data:   data,
+		nodes:  int(le.Uint64(data[24:])),
+		packed: int(le.Uint64(data[32:])),
+		edges:  int(le.Uint64(data[40:])),
+	}
+	// Every node and edge takes at least a byte, which also keeps the section
+	// sizes below from overflowing.
+	if p.nodes < 0 || p.nodes > len(data) || p.packed < 0 || p.packed > p.nodes || p.edges < 0 || p.edges > len(data) {
+		return nil, fmt.Errorf("PDG header is corrupt")
+	}
+	nsamples := int(le.Uint32(data[20:]))
+	p.words = (nsamples + 63) / 64
+	for i := range p.sections {
+		offset := le.Uint64(data[48+16*i:])
+		length := le.Uint64(data[56+16*i:])
+		if offset > uint64(len(data)) || length > uint64(len(data))-offset {
+			return nil, fmt.Errorf("PDG section %v runs past the end of the file", i)
+		}
+		p.sections[i] = data[offset : offset+length]
+	}
+	sizes := map[int]int{
+		pdgKmers:   p.packed * 8,
+		pdgOther:   (p.nodes - p.packed) * p.K,
+		pdgUIDs:    p.nodes * 8,
+		pdgByUID:   p.nodes * 4,
+		pdgOffsets: (p.nodes + 1) * 8,
+		pdgTargets: p.edges * 4,
+		pdgWeights: p.edges * 4,
+		pdgColours: p.nodes * p.words * 8,
+	}
+	for i, size := range sizes {
+		if len(p.sections[i]) != size {
+			return nil, fmt.Errorf("PDG section %v is %v bytes, expected %v", i, len(p.sections[i]), size)
+		}
+	}
+	// Lookups by uid and edge ranges index straight into other sections, so
+	// check them now rather than panic on a corrupt file later.
+	byUID := p.sections[pdgByUID]
+	for i := 0; i < p.nodes; i++ {
+		n := int(le.Uint32(byUID[4*i:]))
+		if n >= p.nodes {
+			return nil, fmt.Errorf("PDG uid index refers to node %v of %v", n, p.nodes)
+		}
+		if i > 0 && p.uid(n) <= p.uid(int(le.Uint32(byUID[4*(i-1):]))) {
+			return nil, fmt.Errorf("PDG uid index isn't sorted by uid")
+		}
+	}
+	offsets := p.sections[pdgOffsets]
+	for i := 0; i <= p.nodes; i++ {
+		offset := le.Uint64(offsets[8*i:])
+		if offset > uint64(p.edges) || (i > 0 && offset < le.Uint64(offsets[8*(i-1):])) {
+			return nil, fmt.Errorf("PDG edge offsets are corrupt")
+		}
+	}
+
+	s := p.sections[pdgSamples]
+	pos := 0
+	readUint32 := func() (uint32, error) {
+		if pos+4 > len(s) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		pos += 4
+		return le.Uint32(s[pos-4:]), nil
+	}
+	readUint64 := func() (uint64, error) {
+		if pos+8 > len(s) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		pos += 8
+		return le.Uint64(s[pos-8:]), nil
+	}
+	readString := func() (string, error) {
+		n, err := readUint32()
+		if err != nil {
+			return "", err
+		}
+		if pos+int(n) > len(s) {
+			return "", io.ErrUnexpectedEOF
+		}
+		pos += int(n)
+		return string(s[pos-int(n) : pos]), nil
+	}
+	n, err := readUint32()
+	if err != nil || int(n) != nsamples {
+		return nil, fmt.Errorf("PDG sample table is corrupt")
+	}
+	npaths := uint64(len(p.sections[pdgPaths]) / 4)
+	for i := 0; i < nsamples; i++ {
+		name, err := readString()
+		if err != nil {
+			return nil, fmt.Errorf("PDG sample table is corrupt")
+		}
+		ncontigs, err := readUint32()
+		if err != nil {
+			return nil, fmt.Errorf("PDG sample table is corrupt")
+		}
+		var contigs []string
+		paths := make(map[string][2]uint64)
+		for j := uint32(0); j < ncontigs; j++ {
+			header, err := readString()
+			if err != nil {
+				return nil, fmt.Errorf("PDG sample table is corrupt")
+			}
+			offset, err := readUint64()
+			if err != nil {
+				return nil, fmt.Errorf("PDG sample table is corrupt")
+			}
+			length, err := readUint64()
+			if err != nil || offset > npaths || length > npaths-offset {
+				return nil, fmt.Errorf("PDG sample table is corrupt")
+			}
+			contigs = append(contigs, header)
+			paths[header] = [2]uint64{offset, length}
+		}
+		p.samples = append(p.samples, name)
+		p.contigs = append(p.contigs, contigs)
+		p.paths = append(p.paths, paths)
+	}
+	return p, nil
+}
+
+// Close unmaps the file.
+func (p *PDG) Close() {
+	if p.close != nil {
+		p.close()
+		p.close = nil
+	}
+}
+
+// uid returns the uid of node n.
+func (p *PDG) uid(n int) uint64 {
+	return binary.LittleEndian.Uint64(p.sections[pdgUIDs][8*n:])
+}
+
+// kmer returns the sequence of node n.
+func (p *PDG) kmer(n int) string {
+	if n < p.packed {
+		return unpackKmer(binary.LittleEndian.Uint64(p.sections[pdgKmers][8*n:]), p.K)
+	}
+	i := (n - p.packed) * p.K
+	return string(p.sections[pdgOther][i : i+p.K])
+}
+
+// find returns the node number of a k-mer.
+func (p *PDG) find(seq string) (int, bool) {
+	if len(seq) != p.K {
+		return 0, false
+	}
+	if v, ok := packKmer(seq); ok {
+		kmers := p.sections[pdgKmers]
+		n := sort.Search(p.packed, func(i int) bool {
+			return binary.LittleEndian.Uint64(kmers[8*i:]) >= v
+		})
+		return n, n < p.packed && binary.LittleEndian.Uint64(kmers[8*n:]) == v
+	}
+	other := p.sections[pdgOther]
+	n := sort.Search(p.nodes-p.packed, func(i int) bool {
+		return string(other[i*p.K:(i+1)*p.K]) >= seq
+	})
+	return p.packed + n, n < p.nodes-p.packed && string(other[n*p.K:(n+1)*p.K]) == seq
+}
+
+// node returns the node number of a uid.
+func (p *PDG) node(uid uint64) (int, bool) {
+	byUID := p.sections[pdgByUID]
+	at := func(i int) int { return int(binary.LittleEndian.Uint32(byUID[4*i:])) }
+	i := sort.Search(p.nodes, func(i int) bool { return p.uid(at(i)) >= uid })
+	if i == p.nodes || p.uid(at(i)) != uid {
+		return 0, false
+	}
+	return at(i), true
+}
+
+// Samples returns the names of all samples in the file.
+func (p *PDG) Samples() ([]string, error) {
+	return append([]string{}, p.samples...), nil
+}
+
+// HasSample returns true if the file has a sample named name.
+func (p *PDG) HasSample(name string) (bool, error) {
+	for _, s := range p.samples {
+		if s == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SampleContigs returns the headers of a sample's contig paths.
+func (p *PDG) SampleContigs(name string) ([]string, error) {
+	for i, s := range p.samples {
+		if s == name {
+			return append([]string{}, p.contigs[i]...), nil
+		}
+	}
+	return nil, fmt.Errorf("sample %s is not in the file", name)
+}
+
+// ContigPath returns the path of uids of a contig of a sample.
+func (p *PDG) ContigPath(sample string, header string) ([]uint64, error) {
+	for i, s := range p.samples {
+		if s == sample {
+			return p.path(i, header)
+		}
+	}
+	return nil, fmt.Errorf("sample %s is not in the file", sample)
+}
+
+// path returns the path of uids of a contig of the sample at index i.
+func (p *PDG) path(i int, header string) ([]uint64, error) {
+	bounds, ok := p.paths[i][header]
+	if !ok {
+		return nil, fmt.Errorf("contig %s is not in the file", header)
+	}
+	paths := p.sections[pdgPaths]
+	path := make([]uint64, bounds[1])
+	for i := range path {
+		n := int(binary.LittleEndian.Uint32(paths[4*(bounds[0]+uint64(i)):]))
+		if n >= p.nodes {
+			return nil, fmt.Errorf("contig %s visits node %v of %v", header, n, p.nodes)
+		}
+		path[i] = p.uid(n)
+	}
+	return path, nil
+}
+
+// LookupKmers returns the uid of each k-mer found in the file.
+func (p *PDG) LookupKmers(seqs []string) (map[string]uint64, error) {
+	found := make(map[string]uint64, len(seqs))
+	for _, seq := range seqs {
+		if n, ok := p.find(seq); ok {
+			found[seq] = p.uid(n)
+		}
+	}
+	return found, nil
+}
+
+// Node returns the sequence of the node with the given uid.
+func (p *PDG) Node(uid uint64) (string, bool) {
+	n, ok := p.node(uid)
+	if !ok {
+		return "", false
+	}
+	return p.kmer(n), true
+}
+
+// Forward returns the successors of a node with their edge weights.
+func (p *PDG) Forward(uid uint64) map[uint64]int {
+	n, ok := p.node(uid)
+	if !ok {
+		return nil
+	}
+	le := binary.LittleEndian
+	start := le.Uint64(p.sections[pdgOffsets][8*n:])
+	end := le.Uint64(p.sections[pdgOffsets][8*(n+1):])
+	edges := make(map[uint64]int, end-start)
+	for i := start; i < end; i++ {
+		dst := int(le.Uint32(p.sections[pdgTargets][4*i:]))
+		if dst < p.nodes {
+			edges[p.uid(dst)] = int(le.Uint32(p.sections[pdgWeights][4*i:]))
+		}
+	}
+	return edges
+}
+
+// Colours returns the samples containing a node.
+func (p *PDG) Colours(uid uint64) Colours {
+	n, ok := p.node(uid)
+	if !ok {
+		return nil
+	}
+	var c Colours
+	for i := 0; i < p.words; i++ {
+		word := binary.LittleEndian.Uint64(p.sections[pdgColours][8*(n*p.words+i):])
+		c = append(c, word)
+	}
+	// Trim to match colours built by setting bits.
+	for len(c) > 0 && c[len(c)-1] == 0 {
+		c = c[:len(c)-1]
+	}
+	return c
+}
+
+// Locate finds a query sequence in the file; see Graph.Locate.
+func (p *PDG) Locate(seq string) (*Location, error) {
+	fwd, rev := queryKmers(seq, p.K)
+	found, err := p.LookupKmers(append(append([]string{}, fwd...), rev...))
+	if err != nil {
+		return nil, err
+	}
+	paths := func(fn func(string, string, []uint64) error) error {
+		for i, sample := range p.samples {
+			for _, header := range p.contigs[i] {
+				path, err := p.path(i, header)
+				if err != nil {
+					return err
+				}
+				if err := fn(sample, header, path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return locate(fwd, rev, p.K, found, paths)
+}
+
+// LoadIndex reads every sample, contig path and node into memory, as
+// Graph.LoadIndex does.
+func (p *PDG) LoadIndex() (*Index, error) {
+	idx := NewIndex(p.K)
+	for i, sample := range p.samples {
+		idx.Samples = append(idx.Samples, sample)
+		idx.Contigs = append(idx.Contigs, append([]string{}, p.contigs[i]...))
+		for _, header := range p.contigs[i] {
+			path, err := p.path(i, header)
+			if err != nil {
+				return nil, err
+			}
+			idx.AddPath(i, header, path)
+		}
+	}
+	for n := 0; n < p.nodes; n++ {
+		idx.Sequences[p.uid(n)] = p.kmer(n)
+	}
+	return idx, nil
+}
+
+// Stats computes summary statistics over the file, with its size in Bytes.
+func (p *PDG) Stats() (*Stats, error) {
+	idx, err := p.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	s := idx.Stats()
+	s.Bytes["pdg"] = int64(len(p.data))
+	return s, nil
+}
+
+// SampleHaplotype simulates a haplotype; see Graph.SampleHaplotype.
+func (p *PDG) SampleHaplotype(start string, end string, rng *rand.Rand) (string, error) {
+	idx, err := p.LoadIndex()
+	if err != nil {
+		return "", err
+	}
+	return idx.SampleHaplotype(start, end, rng, DefaultSimulationOptions)
+}
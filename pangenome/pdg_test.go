@@ -0,0 +1,120 @@
+package pangenome
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+func ExampleOpenPDG() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(filepath.Join(dir, "store"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	for _, genome := range []struct{ name, seq string }{
+		{"a", "GATTACAGCGTCCATGG"},
+		{"b", "GATTACAGCTTNCATGG"},
+	} {
+		a := &Annotated{Headers: []string{">" + genome.name}, Sequences: []string{genome.seq}}
+		if _, err := g.AddAnnotated(genome.name, a, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	idx, err := g.LoadIndex()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	path := filepath.Join(dir, "graph.pdg")
+	f, err := os.Create(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if err := WritePDG(f, idx); err != nil {
+		fmt.Println(err)
+		return
+	}
+	f.Close()
+
+	p, err := OpenPDG(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer p.Close()
+
+	var q Querier = p
+	loaded, _ := q.LoadIndex()
+	fmt.Println(reflect.DeepEqual(idx, loaded))
+	want, _ := g.Locate("CAGCTTNCA")
+	got, _ := q.Locate("CAGCTTNCA")
+	fmt.Println(reflect.DeepEqual(want, got))
+	found, _ := q.LookupKmers([]string{"ACAGC", "TTNCA", "AAAAA"})
+	uid, _ := idx.Lookup("ACAGC")
+	fmt.Println(len(found), found["ACAGC"] == uid)
+	contigs, _ := q.SampleContigs("b")
+	fmt.Println(contigs)
+
+	for dst, w := range p.Forward(uid) {
+		seq, _ := p.Node(dst)
+		fmt.Println(seq, w, reflect.DeepEqual(p.Colours(dst), idx.Colours[dst]))
+	}
+	fmt.Println(p.Colours(uid).Indices())
+
+	data, _ := ioutil.ReadFile(path)
+	_, err = ReadPDG(data[:100])
+	fmt.Println(err)
+	data[48+16*pdgUIDs+8]++
+	_, err = ReadPDG(data)
+	fmt.Println(err)
+	data[48+16*pdgUIDs+8]--
+
+	// Corrupt the uid index and edge offsets, which lookups index by.
+	le := binary.LittleEndian
+	byUID := le.Uint64(data[48+16*pdgByUID:])
+	le.PutUint32(data[byUID:], 1000)
+	_, err = ReadPDG(data)
+	fmt.Println(err)
+	le.PutUint32(data[byUID:], le.Uint32(data[byUID+4:]))
+	_, err = ReadPDG(data)
+	fmt.Println(err)
+	data, _ = ioutil.ReadFile(path)
+	offsets := le.Uint64(data[48+16*pdgOffsets:])
+	le.PutUint64(data[offsets+8:], 1000)
+	_, err = ReadPDG(data)
+	fmt.Println(err)
+	data, _ = ioutil.ReadFile(path)
+	le.PutUint64(data[24:], 1<<62)
+	_, err = ReadPDG(data)
+	fmt.Println(err)
+	// Unordered output:
+	// true
+	// true
+	// 2 true
+	// [>b]
+	// CAGCG 1 true
+	// CAGCT 1 true
+	// [0 1]
+	// not a PDG file
+	// PDG section 3 is 161 bytes, expected 160
+	// PDG uid index refers to node 1000 of 20
+	// PDG uid index isn't sorted by uid
+	// PDG edge offsets are corrupt
+	// PDG header is corrupt
+}
@@ -0,0 +1,94 @@
+package pangenome
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Badger keys.
+const (
+	kmerPrefix    = "kmer/"    // kmer/<sequence>: uid
+	nodePrefix    = "node/"    // node/<uid>: sequence
+	pathPrefix    = "path/"    // path/<sample>/<header>: contig path of uids.
+	samplesKey    = "samples"  // ordered list of sample names.
+	samplePrefix  = "sample/"  // sample/<name>: contig headers
+	nextUIDKey    = "nextuid"  // next free uid in an embedded graph.
+	kKey          = "k"        // length of the k-mers the store was built with.
+	pendingPrefix = "pending/" // pending/<sequence>: a node being created in Dgraph.
+)
+
+func nodeKey(uid uint64) string {
+	return nodePrefix + strconv.FormatUint(uid, 10)
+}
+
+// pathKey is keyed by sample as well as header, since assemblers name contigs
+// alike (>contig1, >NODE_1_length_...) in every genome.
+func pathKey(sample string, header string) string {
+	return pathPrefix + sample + "/" + header
+}
+
+// AddSample registers a named sample with the headers of its contig paths.
+// Samples keep the order they were added in.
+func (g *Graph) AddSample(name string, headers []string) error {
+	exists, err := g.HasSample(name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("sample %s already exists", name)
+	}
+	samples, err := g.Samples()
+	if err != nil {
+		return err
+	}
+	hbuf, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	sbuf, err := json.Marshal(append(samples, name))
+	if err != nil {
+		return err
+	}
+	// Register the sample in one transaction, so it's never listed without
+	// its contigs or the other way round.
+	return g.bd.Update(func(txn *badger.Txn) error {
+		if err := txn.Set([]byte(samplePrefix+name), hbuf); err != nil {
+			return err
+		}
+		return txn.Set([]byte(samplesKey), sbuf)
+	})
+}
+
+// HasSample returns true if a sample has been registered under name.
+func (g *Graph) HasSample(name string) (bool, error) {
+	_, err := g.GetKVSliceStr(samplePrefix + name)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Samples returns the names of all samples in the graph.
+func (g *Graph) Samples() ([]string, error) {
+	samples, err := g.GetKVSliceStr(samplesKey)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	return samples, err
+}
+
+// SampleContigs returns the headers of the contig paths stored for a sample.
+func (g *Graph) SampleContigs(name string) ([]string, error) {
+	return g.GetKVSliceStr(samplePrefix + name)
+}
+
+// ContigPath returns the path of uids stored for a contig header of a sample.
+func (g *Graph) ContigPath(sample string, header string) ([]uint64, error) {
+	return g.GetKVSliceUint64(pathKey(sample, header))
+}
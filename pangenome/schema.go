@@ -0,0 +1,215 @@
+package pangenome
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/dgraph-io/badger"
+)
+
+const schemaKey = "schema" // layout version of the store.
+
+// SchemaVersion is the layout of stores written by this version of
+// prairiedog. Version 1 is every store from before the version was kept.
+const SchemaVersion = 2
+
+// SchemaStep upgrades a store from the version before to Version. Steps
+// must be safe to rerun, as the version is only recorded once one finishes.
+type SchemaStep struct {
+	Version     int
+	Description string
+	apply       func(g *Graph) error
+}
+
+// SchemaSteps are every upgrade, in order.
+var SchemaSteps = []SchemaStep{
+	{2, "move contig paths from keys named by their raw fasta header to path/<sample>/<header>", movePaths},
+}
+
+// PendingSteps returns the steps upgrading a store from version.
+func PendingSteps(version int) []SchemaStep {
+	var steps []SchemaStep
+	for _, step := range SchemaSteps {
+		if step.Version > version {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// StoredSchema returns the layout version of the store. A store without a
+// version is at version 1 if it holds anything, else it's new and at
+// SchemaVersion.
+func (g *Graph) StoredSchema() (int, error) {
+	v, err := g.GetKVInt(schemaKey)
+	if err == nil {
+		return v, nil
+	}
+	if err != badger.ErrKeyNotFound {
+		return 0, err
+	}
+	empty, err := g.empty()
+	if err != nil {
+		return 0, err
+	}
+	if empty {
+		return SchemaVersion, nil
+	}
+	return 1, nil
+}
+
+// setSchema records the layout version of the store.
+func (g *Graph) setSchema(version int) error {
+	_, err := g.SetKVInt(schemaKey, version)
+	return err
+}
+
+// Upgrade runs every pending step, recording the version after each, and
+// returns the steps run.
+func (g *Graph) Upgrade() ([]SchemaStep, error) {
+	v, err := g.StoredSchema()
+	if err != nil {
+		return nil, err
+	}
+	if v > SchemaVersion {
+		return nil, newerSchemaError(v)
+	}
+	steps := PendingSteps(v)
+	for i, step := range steps {
+		log.Printf("Upgrading store to schema v%v: %s.", step.Version, step.Description)
+		if err := step.apply(g); err != nil {
+			return steps[:i], fmt.Errorf("upgrading to schema v%v: %v", step.Version, err)
+		}
+		if err := g.setSchema(step.Version); err != nil {
+			return steps[:i], err
+		}
+	}
+	return steps, g.setSchema(SchemaVersion)
+}
+
+func newerSchemaError(v int) error {
+	return fmt.Errorf("store has schema v%v, newer than v%v known to prairiedog v%s; upgrade prairiedog", v, SchemaVersion, Version)
+}
+
+// checkSchema refuses stores newer than this version, and upgrades older
+// ones unless read-only, in which case they're refused too.
+func (g *Graph) checkSchema(readOnly bool) error {
+	v, err := g.StoredSchema()
+	if err != nil {
+		return err
+	}
+	switch {
+	case v > SchemaVersion:
+		return newerSchemaError(v)
+	case v < SchemaVersion && readOnly:
+		return fmt.Errorf("store has schema v%v, older than v%v; run prairiedog upgrade", v, SchemaVersion)
+	case readOnly:
+		return nil
+	}
+	_, err = g.Upgrade()
+	return err
+}
+
+// movePaths moves each contig path from its raw header to
+// path/<sample>/<header>. Samples sharing a header shared its key too, so only
+// the path of the last of them to be added survived; it's moved to that
+// sample, and the header is dropped from the contigs of the others, which are
+// logged. Stores from before samples were kept have paths under headers no
+// sample holds; they're refused, as nothing tells which genome a path is of.
+func movePaths(g *Graph) error {
+	samples, err := g.Samples()
+	if err != nil {
+		return err
+	}
+	orphans, err := g.orphanPaths(samples)
+	if err != nil {
+		return err
+	}
+	if len(orphans) > 0 {
+		return fmt.Errorf("store holds paths of %v contigs no sample holds, such as %s, so it's from before samples were kept; add its genomes again to a new store", len(orphans), orphans[0])
+	}
+	owners := make(map[string]string) // header: sample holding its path.
+	b := g.newBatch()
+	for i := len(samples) - 1; i >= 0; i-- {
+		sample := samples[i]
+		headers, err := g.SampleContigs(sample)
+		if err != nil {
+			return err
+		}
+		kept := make([]string, 0, len(headers))
+		for _, header := range headers {
+			if owner, ok := owners[header]; ok {
+				log.Printf("WARNING: sample %s lost the path of contig %s to sample %s, which has the same header. Add %s again to restore it.", sample, header, owner, sample)
+				continue
+			}
+			val, err := g.GetKVStr(header)
+			if err == badger.ErrKeyNotFound {
+				// Already moved, if this step is being rerun.
+				_, err := g.GetKVStr(pathKey(sample, header))
+				if err == badger.ErrKeyNotFound {
+					log.Printf("WARNING: sample %s has no path for contig %s. Add %s again to restore it.", sample, header, sample)
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				owners[header] = sample
+				kept = append(kept, header)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := b.set([]byte(pathKey(sample, header)), []byte(val)); err != nil {
+				return err
+			}
+			if err := b.delete([]byte(header)); err != nil {
+				return err
+			}
+			owners[header] = sample
+			kept = append(kept, header)
+		}
+		if len(kept) == len(headers) {
+			continue
+		}
+		buf, err := json.Marshal(kept)
+		if err != nil {
+			return err
+		}
+		if err := b.set([]byte(samplePrefix+sample), buf); err != nil {
+			return err
+		}
+	}
+	return b.flush()
+}
+
+// orphanPaths returns the raw fasta headers in Badger that none of samples
+// has a contig of.
+func (g *Graph) orphanPaths(samples []string) ([]string, error) {
+	held := make(map[string]bool)
+	for _, sample := range samples {
+		headers, err := g.SampleContigs(sample)
+		if err != nil {
+			return nil, err
+		}
+		for _, header := range headers {
+			held[header] = true
+		}
+	}
+	var orphans []string
+	err := g.bd.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		p := []byte(">")
+		for it.Seek(p); it.ValidForPrefix(p); it.Next() {
+			if header := string(it.Item().Key()); !held[header] {
+				orphans = append(orphans, header)
+			}
+		}
+		return nil
+	})
+	return orphans, err
+}
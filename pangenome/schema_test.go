@@ -0,0 +1,167 @@
+package pangenome
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dgraph-io/badger"
+)
+
+func ExampleGraph_Upgrade() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	store := filepath.Join(dir, "store")
+
+	// Lay the store out as it was before schema versions.
+	g, err := openTestGraph(store)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	a := &Annotated{Headers: []string{">a"}, Sequences: []string{"GATTACAGCGTCCATGG"}}
+	if _, err := g.AddAnnotated("a", a, context.Background()); err != nil {
+		fmt.Println(err)
+		return
+	}
+	path, _ := g.ContigPath("a", ">a")
+	g.SetKVSliceUint64(">a", path)
+	g.dropPrefix(pathPrefix)
+	g.dropPrefix(schemaKey)
+	g.Close()
+
+	opts := DefaultOptions
+	opts.Dir = store
+	opts.Embedded = true
+	opts.ReadOnly = true
+	_, err = Open(opts)
+	fmt.Println(err)
+
+	opts.ReadOnly = false
+	opts.NoUpgrade = true
+	g, err = Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	v, _ := g.StoredSchema()
+	for _, step := range PendingSteps(v) {
+		fmt.Printf("v%v to v%v: %s\n", v, step.Version, step.Description)
+	}
+	steps, err := g.Upgrade()
+	fmt.Println(len(steps), err)
+	upgraded, _ := g.ContigPath("a", ">a")
+	fmt.Println(fmt.Sprint(upgraded) == fmt.Sprint(path))
+	v, _ = g.StoredSchema()
+	fmt.Println(v)
+
+	g.SetKVInt(schemaKey, SchemaVersion+1)
+	g.Close()
+	opts.NoUpgrade = false
+	_, err = Open(opts)
+	fmt.Println(err)
+	// Output:
+	// store has schema v1, older than v2; run prairiedog upgrade
+	// v1 to v2: move contig paths from keys named by their raw fasta header to path/<sample>/<header>
+	// 1 <nil>
+	// true
+	// 2
+	// store has schema v3, newer than v2 known to prairiedog v0.0.1; upgrade prairiedog
+}
+
+func ExampleGraph_Upgrade_sharedHeaders() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	genomes := []struct {
+		name string
+		a    *Annotated
+	}{
+		{"a", &Annotated{Headers: []string{">contig1", ">contig2"}, Sequences: []string{"GATTACAGCG", "CCCGGGTTTA"}}},
+		{"b", &Annotated{Headers: []string{">contig1"}, Sequences: []string{"TTTTCCATGG"}}},
+	}
+	for _, genome := range genomes {
+		if _, err := g.AddAnnotated(genome.name, genome.a, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	// A v1 store kept paths under their raw headers, so b's path of
+	// >contig1 replaced a's.
+	a2, _ := g.ContigPath("a", ">contig2")
+	b1, _ := g.ContigPath("b", ">contig1")
+	g.dropPrefix(pathPrefix)
+	g.dropPrefix(schemaKey)
+	g.SetKVSliceUint64(">contig2", a2)
+	g.SetKVSliceUint64(">contig1", b1)
+
+	// Upgrading twice shows the step can be rerun.
+	for i := 0; i < 2; i++ {
+		g.setSchema(1)
+		if _, err := g.Upgrade(); err != nil {
+			fmt.Println(err)
+			return
+		}
+		for _, genome := range genomes {
+			contigs, _ := g.SampleContigs(genome.name)
+			for _, contig := range contigs {
+				path, _ := g.ContigPath(genome.name, contig)
+				fmt.Println(genome.name, contig, path)
+			}
+		}
+	}
+	_, err = g.GetKVStr(">contig1")
+	fmt.Println(err == badger.ErrKeyNotFound)
+	// Output:
+	// a >contig2 [7 8 9 10 11 12]
+	// b >contig1 [13 14 15 16 17 18]
+	// a >contig2 [7 8 9 10 11 12]
+	// b >contig1 [13 14 15 16 17 18]
+	// true
+}
+
+func ExampleGraph_Upgrade_noSamples() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	store := filepath.Join(dir, "store")
+
+	// Lay the store out as the first prairiedog did: a path under each raw
+	// header, and nothing else.
+	g, err := openTestGraph(store)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	g.dropPrefix("")
+	g.SetKVSliceUint64(">contig1", []uint64{1, 2, 3})
+	g.Close()
+
+	opts := DefaultOptions
+	opts.Dir = store
+	opts.Embedded = true
+	_, err = Open(opts)
+	fmt.Println(err)
+	// Output:
+	// upgrading to schema v2: store holds paths of 1 contigs no sample holds, such as >contig1, so it's from before samples were kept; add its genomes again to a new store
+}
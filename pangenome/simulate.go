@@ -0,0 +1,171 @@
+package pangenome
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// SimulationOptions are the parameters of the Li-Stephens model used to
+// simulate haplotypes. At every step the walk copies from a template sample;
+// with probability Recombination it switches to another sample found at the
+// current node. Each emitted base is mutated with probability Mutation.
+type SimulationOptions struct {
+	Recombination float64
+	Mutation      float64
+	MaxLength     int // longest haplotype, in bases.
+	Attempts      int // walks tried before giving up on reaching the end.
+}
+
+// DefaultSimulationOptions copy long stretches from each sample without
+// adding mutations.
+var DefaultSimulationOptions = SimulationOptions{
+	Recombination: 0.001,
+	Mutation:      0,
+	MaxLength:     10000000,
+	Attempts:      100,
+}
+
+var bases = []byte("ACGT")
+
+// SampleHaplotype simulates a haplotype by a weighted random walk from the
+// start k-mer to the end k-mer. If end is empty the walk runs until it reaches
+// a node with no outgoing edges. Walks are reproducible for a given rng seed.
+func (g *Graph) SampleHaplotype(start string, end string, rng *rand.Rand) (string, error) {
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return "", err
+	}
+	return idx.SampleHaplotype(start, end, rng, DefaultSimulationOptions)
+}
+
+// SampleHaplotype simulates a haplotype over the index; see
+// Graph.SampleHaplotype.
+func (idx *Index) SampleHaplotype(start string, end string, rng *rand.Rand, opts SimulationOptions) (string, error) {
+	src, ok := idx.Lookup(start)
+	if !ok {
+		return "", fmt.Errorf("start k-mer %s is not in the graph", start)
+	}
+	var dst uint64
+	if end != "" {
+		dst, ok = idx.Lookup(end)
+		if !ok {
+			return "", fmt.Errorf("end k-mer %s is not in the graph", end)
+		}
+	}
+
+	for attempt := 0; attempt < opts.Attempts; attempt++ {
+		path := idx.walk(src, dst, end != "", rng, opts)
+		if path != nil {
+			return mutate(idx.Spell(path), rng, opts.Mutation), nil
+		}
+	}
+	return "", fmt.Errorf("no walk from %s reached %s in %v attempts", start, end, opts.Attempts)
+}
+
+// walk returns a random path from src, or nil if it has to reach dst and
+// doesn't.
+func (idx *Index) walk(src uint64, dst uint64, bounded bool, rng *rand.Rand, opts SimulationOptions) []uint64 {
+	template := idx.pickSample(src, rng)
+	path := []uint64{src}
+	cur := src
+	for len(path)+idx.K-1 < opts.MaxLength {
+		if bounded && cur == dst {
+			return path
+		}
+		succ, weights := idx.successors(cur)
+		if len(succ) == 0 {
+			break
+		}
+		if rng.Float64() < opts.Recombination {
+			template = idx.pickSample(cur, rng)
+		}
+
+		// Follow the template where it continues, otherwise fall back to
+		// every successor and pick up a new template there.
+		var onTemplate []uint64
+		var templateWeights []int
+		for i, uid := range succ {
+			if idx.Colours[uid].Has(template) {
+				onTemplate = append(onTemplate, uid)
+				templateWeights = append(templateWeights, weights[i])
+			}
+		}
+		if len(onTemplate) > 0 {
+			cur = onTemplate[pickWeighted(templateWeights, rng)]
+		} else {
+			cur = succ[pickWeighted(weights, rng)]
+			template = idx.pickSample(cur, rng)
+		}
+		path = append(path, cur)
+	}
+	if bounded && cur != dst {
+		return nil
+	}
+	return path
+}
+
+// successors returns the successors of a node, sorted so walks are
+// reproducible, along with their edge weights.
+func (idx *Index) successors(uid uint64) ([]uint64, []int) {
+	succ := make([]uint64, 0, len(idx.Forward[uid]))
+	for v := range idx.Forward[uid] {
+		succ = append(succ, v)
+	}
+	sort.Slice(succ, func(i, j int) bool { return succ[i] < succ[j] })
+	weights := make([]int, len(succ))
+	for i, v := range succ {
+		weights[i] = idx.Forward[uid][v]
+	}
+	return succ, weights
+}
+
+// pickSample returns a sample found at the node, uniformly at random.
+func (idx *Index) pickSample(uid uint64, rng *rand.Rand) int {
+	samples := idx.Colours[uid].Indices()
+	if len(samples) == 0 {
+		return -1
+	}
+	return samples[rng.Intn(len(samples))]
+}
+
+// pickWeighted returns an index into weights with probability proportional to
+// its weight.
+func pickWeighted(weights []int, rng *rand.Rand) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	if total == 0 {
+		return rng.Intn(len(weights))
+	}
+	r := rng.Intn(total)
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
+
+// mutate replaces each base with a different one with probability rate.
+func mutate(seq string, rng *rand.Rand, rate float64) string {
+	if rate <= 0 {
+		return seq
+	}
+	b := []byte(seq)
+	for i, c := range b {
+		if rng.Float64() >= rate {
+			continue
+		}
+		others := make([]byte, 0, len(bases))
+		for _, o := range bases {
+			if o != c {
+				others = append(others, o)
+			}
+		}
+		b[i] = others[rng.Intn(len(others))]
+	}
+	return string(b)
+}
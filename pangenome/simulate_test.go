@@ -0,0 +1,24 @@
+package pangenome
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+func ExampleIndex_SampleHaplotype() {
+	idx := NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGGAACTTGCAAGTCGGATCCTAGA"})
+	idx.AddGenome("b", []string{">b"}, []string{"GATTACAGCTTCCATGGAACTTGCAAGTGGATCCTAGA"})
+
+	opts := DefaultSimulationOptions
+	opts.Recombination = 0.5
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 3; i++ {
+		seq, err := idx.SampleHaplotype("GATTA", "CTAGA", rng, opts)
+		fmt.Println(seq, err)
+	}
+	// Output:
+	// GATTACAGCTTCCATGGAACTTGCAAGTGGATCCTAGA <nil>
+	// GATTACAGCTTCCATGGAACTTGCAAGTGGATCCTAGA <nil>
+	// GATTACAGCGTCCATGGAACTTGCAAGTCGGATCCTAGA <nil>
+}
@@ -0,0 +1,43 @@
+package pangenome
+
+import (
+	"encoding/json"
+
+	"github.com/dgraph-io/badger"
+	"github.com/superphy/prairiedog/kmers"
+)
+
+const sketchPrefix = "sketch/" // sketch/<sample>: MinHash sketch.
+
+// SetSketch stores the MinHash sketch of a sample.
+func (g *Graph) SetSketch(sample string, s *kmers.Sketch) error {
+	buf, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return g.bd.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(sketchPrefix+sample), buf)
+	})
+}
+
+// Sketch returns the MinHash sketch of a sample, or nil if it has none, as
+// with samples added before sketches were stored.
+func (g *Graph) Sketch(sample string) (*kmers.Sketch, error) {
+	var s *kmers.Sketch
+	err := g.bd.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(sketchPrefix + sample))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &s)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
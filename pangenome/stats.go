@@ -0,0 +1,172 @@
+package pangenome
+
+import (
+	"sort"
+)
+
+// Stats summarises what is in a store.
+type Stats struct {
+	Nodes          int              `json:"nodes"`
+	Edges          int              `json:"edges"`
+	Samples        int              `json:"samples"`
+	InDegrees      map[int]int      `json:"in_degrees"`  // degree: number of nodes.
+	OutDegrees     map[int]int      `json:"out_degrees"` // degree: number of nodes.
+	BranchingNodes int              `json:"branching_nodes"`
+	Components     int              `json:"components"` // weakly connected.
+	Unitigs        int              `json:"unitigs"`
+	UnitigN50      int              `json:"unitig_n50"`
+	Bytes          map[string]int64 `json:"bytes"` // backend: stored bytes; Dgraph's aren't known.
+	SampleStats    []SampleStats    `json:"sample_stats"`
+}
+
+// SampleStats are the k-mer counts of a single sample. Novel k-mers were first
+// added to the graph by this sample, unique k-mers are found in no other sample.
+type SampleStats struct {
+	Name   string `json:"name"`
+	Kmers  int    `json:"kmers"`
+	Novel  int    `json:"novel"`
+	Unique int    `json:"unique"`
+}
+
+// Stats computes summary statistics over the whole graph, using the stored
+// unitigs if the graph has been compacted. Dgraph doesn't report its on-disk
+// size through the client API, so Bytes only has Badger's, which with Dgraph
+// covers the k-mer index, paths and samples but not the graph itself.
+func (g *Graph) Stats() (*Stats, error) {
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	compacted, err := g.Compacted()
+	if err != nil {
+		return nil, err
+	}
+	s := idx.stats(!compacted)
+
+	lsm, vlog := g.bd.Size()
+	s.Bytes["badger"] = lsm + vlog
+
+	if compacted {
+		unitigs, err := g.Unitigs()
+		if err != nil {
+			return nil, err
+		}
+		s.Unitigs = len(unitigs)
+		s.UnitigN50 = unitigN50(unitigs)
+	}
+	return s, nil
+}
+
+// Stats computes summary statistics over the index. Unitigs are compacted on
+// the fly.
+func (idx *Index) Stats() *Stats {
+	return idx.stats(true)
+}
+
+// stats is Stats, leaving out unitigs unless compact is true.
+func (idx *Index) stats(compact bool) *Stats {
+	s := &Stats{
+		Nodes:      len(idx.Sequences),
+		Samples:    len(idx.Samples),
+		InDegrees:  make(map[int]int),
+		OutDegrees: make(map[int]int),
+		Bytes:      make(map[string]int64),
+	}
+
+	nodes := make([]uint64, 0, len(idx.Sequences))
+	for uid := range idx.Sequences {
+		nodes = append(nodes, uid)
+		in, out := idx.InDegree(uid), idx.OutDegree(uid)
+		s.InDegrees[in]++
+		s.OutDegrees[out]++
+		s.Edges += out
+		if in > 1 || out > 1 {
+			s.BranchingNodes++
+		}
+	}
+	s.Components = idx.components(nodes)
+
+	if compact {
+		unitigs := idx.Unitigs(nodes)
+		s.Unitigs = len(unitigs)
+		s.UnitigN50 = unitigN50(unitigs)
+	}
+
+	s.SampleStats = make([]SampleStats, len(idx.Samples))
+	for i, name := range idx.Samples {
+		s.SampleStats[i].Name = name
+	}
+	for _, c := range idx.Colours {
+		first := -1
+		for i := range idx.Samples {
+			if !c.Has(i) {
+				continue
+			}
+			if first == -1 {
+				first = i
+			}
+			s.SampleStats[i].Kmers++
+		}
+		if first == -1 {
+			continue
+		}
+		s.SampleStats[first].Novel++
+		if c.Count() == 1 {
+			s.SampleStats[first].Unique++
+		}
+	}
+	return s
+}
+
+// components counts weakly connected components with union-find.
+func (idx *Index) components(nodes []uint64) int {
+	parent := make(map[uint64]uint64, len(nodes))
+	var find func(uint64) uint64
+	find = func(x uint64) uint64 {
+		for parent[x] != x {
+			parent[x] = parent[parent[x]]
+			x = parent[x]
+		}
+		return x
+	}
+	for _, uid := range nodes {
+		parent[uid] = uid
+	}
+	n := len(nodes)
+	for src, dsts := range idx.Forward {
+		if _, ok := parent[src]; !ok {
+			continue
+		}
+		for dst := range dsts {
+			if _, ok := parent[dst]; !ok {
+				continue
+			}
+			a, b := find(src), find(dst)
+			if a != b {
+				parent[a] = b
+				n--
+			}
+		}
+	}
+	return n
+}
+
+// unitigN50 returns the length of the shortest unitig such that unitigs at
+// least that long cover half of the total sequence.
+func unitigN50(unitigs []*Unitig) int {
+	lengths := make([]int, len(unitigs))
+	total := 0
+	for i, u := range unitigs {
+		lengths[i] = len(u.Sequence)
+		total += lengths[i]
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lengths)))
+	sum := 0
+	for _, l := range lengths {
+		sum += l
+		if 2*sum >= total {
+			return l
+		}
+	}
+	return 0
+}
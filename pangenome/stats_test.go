@@ -0,0 +1,27 @@
+package pangenome
+
+import (
+	"fmt"
+)
+
+func ExampleIndex_Stats() {
+	idx := newTestIndex(
+		[]uint64{1, 2, 3, 4},
+		[]uint64{5, 2, 3},
+		[]uint64{6, 7},
+	)
+	seqs := map[uint64]string{1: "ACG", 2: "CGT", 3: "GTA", 4: "TAC", 5: "TCG", 6: "AAA", 7: "AAC"}
+	for uid, seq := range seqs {
+		idx.Sequences[uid] = seq
+	}
+	s := idx.Stats()
+	fmt.Println(s.Nodes, s.Edges, s.BranchingNodes, s.Components, s.Unitigs, s.UnitigN50)
+	for _, ss := range s.SampleStats {
+		fmt.Println(ss.Name, ss.Kmers, ss.Novel, ss.Unique)
+	}
+	// Output:
+	// 7 5 1 2 4 4
+	// sample0 4 4 2
+	// sample1 3 1 1
+	// sample2 2 2 2
+}
@@ -0,0 +1,324 @@
+package pangenome
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/dgraph-io/badger"
+)
+
+// Badger keys for the compacted graph.
+const (
+	unitigsKey     = "unitigs"   // next unitig id, present once compacted.
+	unitigPrefix   = "unitig/"   // unitig/<id>: Unitig
+	unitigOfPrefix = "unitigof/" // unitigof/<uid>: unitig id
+)
+
+// Unitig is a maximal non-branching path of k-mer nodes collapsed into a single
+// node. Members are the k-mer uids in path order, Weights[i] is the weight of
+// the edge from Members[i] to Members[i+1] and Colours are the samples visiting
+// any member.
+type Unitig struct {
+	ID       uint64   `json:"id"`
+	Sequence string   `json:"sequence"`
+	Members  []uint64 `json:"members"`
+	Weights  []int    `json:"weights"`
+	Colours  Colours  `json:"colours"`
+}
+
+func unitigKey(id uint64) string {
+	return unitigPrefix + strconv.FormatUint(id, 10)
+}
+
+func unitigOfKey(uid uint64) string {
+	return unitigOfPrefix + strconv.FormatUint(uid, 10)
+}
+
+// joinable returns true if the edge src->dst is the only edge leaving src and
+// the only edge entering dst, so the two nodes belong to the same unitig.
+func (idx *Index) joinable(src, dst uint64) bool {
+	return src != dst && idx.OutDegree(src) == 1 && idx.InDegree(dst) == 1
+}
+
+// Unitigs collapses the given nodes into maximal non-branching paths. Branching
+// is judged against the whole index, so compacting a subset gives the same
+// unitigs as compacting everything as long as the subset is closed under
+// joinable edges. Unitigs are returned without ids.
+func (idx *Index) Unitigs(nodes []uint64) []*Unitig {
+	in := make(map[uint64]bool, len(nodes))
+	for _, uid := range nodes {
+		in[uid] = true
+	}
+	// Iterate in a fixed order so ids are stable between runs.
+	sorted := append([]uint64{}, nodes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	visited := make(map[uint64]bool, len(nodes))
+	var unitigs []*Unitig
+	walk := func(start uint64) {
+		members := []uint64{start}
+		visited[start] = true
+		cur := start
+		for idx.OutDegree(cur) == 1 {
+			var next uint64
+			for next = range idx.Forward[cur] {
+			}
+			if !in[next] || visited[next] || !idx.joinable(cur, next) {
+				break
+			}
+			members = append(members, next)
+			visited[next] = true
+			cur = next
+		}
+		unitigs = append(unitigs, idx.newUnitig(members))
+	}
+
+	// Start from every node which can't be joined to its predecessor.
+	for _, uid := range sorted {
+		if visited[uid] {
+			continue
+		}
+		if idx.InDegree(uid) == 1 {
+			var prev uint64
+			for prev = range idx.Reverse[uid] {
+			}
+			if in[prev] && idx.joinable(prev, uid) {
+				continue
+			}
+		}
+		walk(uid)
+	}
+	// Anything left is on an isolated cycle.
+	for _, uid := range sorted {
+		if !visited[uid] {
+			walk(uid)
+		}
+	}
+	return unitigs
+}
+
+func (idx *Index) newUnitig(members []uint64) *Unitig {
+	u := &Unitig{
+		Sequence: idx.Spell(members),
+		Members:  members,
+		Weights:  make([]int, 0, len(members)-1),
+	}
+	for i, uid := range members {
+		u.Colours = u.Colours.Union(idx.Colours[uid])
+		if i > 0 {
+			u.Weights = append(u.Weights, idx.Forward[members[i-1]][uid])
+		}
+	}
+	return u
+}
+
+// Compacted returns true if the graph has been compacted into unitigs.
+func (g *Graph) Compacted() (bool, error) {
+	_, err := g.GetKVStr(unitigsKey)
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Compact collapses every maximal non-branching path of the graph into a
+// unitig, replacing any previous compaction. It returns the number of unitigs.
+func (g *Graph) Compact() (int, error) {
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return 0, err
+	}
+	if err := g.dropPrefix(unitigPrefix); err != nil {
+		return 0, err
+	}
+	if err := g.dropPrefix(unitigOfPrefix); err != nil {
+		return 0, err
+	}
+	nodes := make([]uint64, 0, len(idx.Sequences))
+	for uid := range idx.Sequences {
+		nodes = append(nodes, uid)
+	}
+	unitigs := idx.Unitigs(nodes)
+	if err := g.storeUnitigs(unitigs, 0); err != nil {
+		return 0, err
+	}
+	return len(unitigs), nil
+}
+
+// updateUnitigs recompacts the unitigs touched by the paths of a newly added
+// sample. Adding edges only ever splits unitigs or extends them across the new
+// edges, so every changed unitig contains a node on one of the new paths.
+func (g *Graph) updateUnitigs(sample string) error {
+	idx, err := g.LoadIndex()
+	if err != nil {
+		return err
+	}
+	headers, err := g.SampleContigs(sample)
+	if err != nil {
+		return err
+	}
+
+	i := -1
+	for j, name := range idx.Samples {
+		if name == sample {
+			i = j
+		}
+	}
+
+	affected := make(map[uint64]bool)
+	nodes := make(map[uint64]bool)
+	for _, header := range headers {
+		for _, uid := range idx.Path(i, header) {
+			nodes[uid] = true
+			id, err := g.GetKVStr(unitigOfKey(uid))
+			if err == badger.ErrKeyNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			n, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return err
+			}
+			affected[n] = true
+		}
+	}
+
+	// Pull in every member of the unitigs that may have to be split.
+	var ids []uint64
+	for id := range affected {
+		u, err := g.GetUnitig(id)
+		if err != nil {
+			return err
+		}
+		for _, uid := range u.Members {
+			nodes[uid] = true
+		}
+		ids = append(ids, id)
+	}
+	list := make([]uint64, 0, len(nodes))
+	for uid := range nodes {
+		list = append(list, uid)
+	}
+
+	next, err := g.GetKVStr(unitigsKey)
+	if err != nil {
+		return err
+	}
+	start, err := strconv.ParseUint(next, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	b := g.newBatch()
+	for _, id := range ids {
+		if err := b.delete([]byte(unitigKey(id))); err != nil {
+			return err
+		}
+	}
+	if err := b.flush(); err != nil {
+		return err
+	}
+	return g.storeUnitigs(idx.Unitigs(list), start)
+}
+
+// storeUnitigs assigns ids to unitigs starting from start and writes them, and
+// the member: unitig mapping, to Badger.
+func (g *Graph) storeUnitigs(unitigs []*Unitig, start uint64) error {
+	b := g.newBatch()
+	id := start
+	for _, u := range unitigs {
+		u.ID = id
+		id++
+		buf, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if err := b.set([]byte(unitigKey(u.ID)), buf); err != nil {
+			return err
+		}
+		for _, uid := range u.Members {
+			if err := b.set([]byte(unitigOfKey(uid)), []byte(strconv.FormatUint(u.ID, 10))); err != nil {
+				return err
+			}
+		}
+	}
+	if err := b.set([]byte(unitigsKey), []byte(strconv.FormatUint(id, 10))); err != nil {
+		return err
+	}
+	return b.flush()
+}
+
+// GetUnitig returns the unitig with the given id.
+func (g *Graph) GetUnitig(id uint64) (*Unitig, error) {
+	var valCopy []byte
+	err := g.bd.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(unitigKey(id)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			valCopy = append([]byte{}, val...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	u := &Unitig{}
+	if err := json.Unmarshal(valCopy, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Unitigs returns every unitig of a compacted graph.
+func (g *Graph) Unitigs() ([]*Unitig, error) {
+	var unitigs []*Unitig
+	err := g.bd.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		prefix := []byte(unitigPrefix)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				u := &Unitig{}
+				if err := json.Unmarshal(val, u); err != nil {
+					return err
+				}
+				unitigs = append(unitigs, u)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(unitigs, func(i, j int) bool { return unitigs[i].ID < unitigs[j].ID })
+	return unitigs, nil
+}
+
+// CompactedUnitigs returns the stored unitigs if the graph has been compacted.
+// Otherwise the index is compacted in memory and nothing is stored.
+func (g *Graph) CompactedUnitigs(idx *Index) ([]*Unitig, error) {
+	compacted, err := g.Compacted()
+	if err != nil {
+		return nil, err
+	}
+	if compacted {
+		return g.Unitigs()
+	}
+	unitigs := idx.Unitigs(idx.Nodes())
+	for i, u := range unitigs {
+		u.ID = uint64(i)
+	}
+	return unitigs, nil
+}
@@ -0,0 +1,102 @@
+package pangenome
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/superphy/prairiedog/kmers"
+)
+
+func newTestIndex(paths ...[]uint64) *Index {
+	idx := NewIndex(3)
+	for i, path := range paths {
+		header := fmt.Sprintf(">contig%v", i)
+		idx.Samples = append(idx.Samples, fmt.Sprintf("sample%v", i))
+		idx.Contigs = append(idx.Contigs, []string{header})
+		idx.AddPath(i, header, path)
+	}
+	return idx
+}
+
+func ExampleIndex_Unitigs() {
+	idx := newTestIndex(
+		[]uint64{1, 2, 3, 4},
+		[]uint64{5, 2, 3},
+	)
+	seqs := map[uint64]string{1: "ACG", 2: "CGT", 3: "GTA", 4: "TAC", 5: "TCG"}
+	for uid, seq := range seqs {
+		idx.Sequences[uid] = seq
+	}
+	for _, u := range idx.Unitigs([]uint64{1, 2, 3, 4, 5}) {
+		fmt.Println(u.Members, u.Sequence, u.Weights, u.Colours.Count())
+	}
+	// Output:
+	// [1] ACG [] 1
+	// [2 3 4] CGTAC [2 1] 2
+	// [5] TCG [] 1
+}
+
+// unitigSet returns the stored unitigs of g by sequence, ignoring their ids.
+func unitigSet(g *Graph) []string {
+	unitigs, err := g.Unitigs()
+	if err != nil {
+		return nil
+	}
+	var set []string
+	for _, u := range unitigs {
+		set = append(set, fmt.Sprintf("%s %v %v %v", u.Sequence, u.Members, u.Weights, u.Colours.Indices()))
+	}
+	sort.Strings(set)
+	return set
+}
+
+func ExampleGraph_Compact() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	g, err := openTestGraph(dir)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	add := func(name string, seq string) {
+		km := kmers.NewFromSequences([]string{">" + name}, []string{seq})
+		km.K = g.K
+		if _, err := g.AddGenome(name, km, context.Background()); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	add("a", "GATTACAGCGTCCATGG")
+	n, _ := g.Compact()
+	fmt.Println(n)
+
+	// A SNP in b splits a's unitig in three, and its own k-mers make a
+	// fourth.
+	add("b", "GATTACAGCTTCCATGG")
+	updated := unitigSet(g)
+	fmt.Println(len(updated))
+	for _, u := range updated {
+		fmt.Println(u)
+	}
+
+	g.Compact()
+	fmt.Println(reflect.DeepEqual(updated, unitigSet(g)))
+	// Output:
+	// 1
+	// 4
+	// CAGCGTCCA [6 7 8 9 10] [1 1 1 1] [0]
+	// CAGCTTCCA [14 15 16 17 18] [1 1 1 1] [1]
+	// GATTACAGC [1 2 3 4 5] [2 2 2 2] [0 1]
+	// TCCATGG [11 12 13] [2 2] [0 1]
+	// true
+}
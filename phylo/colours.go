@@ -0,0 +1,105 @@
+package phylo
+
+import (
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// JaccardDistances returns one minus the Jaccard index of the nodes visited by
+// each pair of samples.
+func JaccardDistances(idx *pangenome.Index) *Distances {
+	sets := make([]pangenome.Colours, 0, len(idx.Colours))
+	for _, c := range idx.Colours {
+		sets = append(sets, c)
+	}
+	return jaccard(idx.Samples, sets)
+}
+
+// EdgeJaccardDistances returns one minus the Jaccard index of the edges
+// traversed by each pair of samples.
+func EdgeJaccardDistances(idx *pangenome.Index) *Distances {
+	edges := make(map[[2]uint64]pangenome.Colours)
+	for i, contigs := range idx.Contigs {
+		for _, contig := range contigs {
+			path := idx.Path(i, contig)
+			for j := 1; j < len(path); j++ {
+				e := [2]uint64{path[j-1], path[j]}
+				c := edges[e]
+				c.Set(i)
+				edges[e] = c
+			}
+		}
+	}
+	sets := make([]pangenome.Colours, 0, len(edges))
+	for _, c := range edges {
+		sets = append(sets, c)
+	}
+	return jaccard(idx.Samples, sets)
+}
+
+// jaccard counts, for every pair of samples, the elements both are coloured
+// in, given the colours of each element.
+func jaccard(names []string, colours []pangenome.Colours) *Distances {
+	n := len(names)
+	sizes := make([]int, n)
+	shared := make([][]int, n)
+	for i := range shared {
+		shared[i] = make([]int, n)
+	}
+	for _, c := range colours {
+		in := c.Indices()
+		for a, i := range in {
+			sizes[i]++
+			for _, j := range in[a+1:] {
+				shared[i][j]++
+			}
+		}
+	}
+	d := NewDistances(names)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			dist := 1.0
+			if union := sizes[i] + sizes[j] - shared[i][j]; union > 0 {
+				dist = 1 - float64(shared[i][j])/float64(union)
+			}
+			d.Set(i, j, dist)
+		}
+	}
+	return d
+}
+
+// AccessoryDistances returns the fraction of accessory unitigs (those outside
+// the core partition) present in exactly one of each pair of samples.
+func AccessoryDistances(idx *pangenome.Index, unitigs []*pangenome.Unitig, t pangenome.Thresholds) *Distances {
+	parts := idx.Partition(unitigs, t)
+	var accessory []*pangenome.Unitig
+	for _, p := range pangenome.Partitions {
+		if p != pangenome.Core {
+			accessory = append(accessory, parts[p]...)
+		}
+	}
+
+	n := len(idx.Samples)
+	diff := make([][]int, n)
+	for i := range diff {
+		diff[i] = make([]int, n)
+	}
+	for _, u := range accessory {
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				if u.Colours.Has(i) != u.Colours.Has(j) {
+					diff[i][j]++
+				}
+			}
+		}
+	}
+	d := NewDistances(idx.Samples)
+	if len(accessory) == 0 {
+		return d
+	}
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d.Set(i, j, float64(diff[i][j])/float64(len(accessory)))
+		}
+	}
+	return d
+}
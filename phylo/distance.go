@@ -0,0 +1,68 @@
+// Package phylo compares samples by pairwise distance and writes the results
+// for phylogenetics tools.
+package phylo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Distances is a symmetric matrix of pairwise distances between named samples.
+type Distances struct {
+	Names []string
+	D     [][]float64
+}
+
+// NewDistances creates a matrix of zero distances.
+func NewDistances(names []string) *Distances {
+	d := &Distances{
+		Names: names,
+		D:     make([][]float64, len(names)),
+	}
+	for i := range d.D {
+		d.D[i] = make([]float64, len(names))
+	}
+	return d
+}
+
+// Set sets the distance between samples i and j.
+func (d *Distances) Set(i int, j int, dist float64) {
+	d.D[i][j] = dist
+	d.D[j][i] = dist
+}
+
+// WritePHYLIP writes the matrix in square PHYLIP format. Names are written in
+// full rather than padded to ten characters, as read by most modern tools.
+func (d *Distances) WritePHYLIP(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%v\n", len(d.Names)); err != nil {
+		return err
+	}
+	for i, name := range d.Names {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", name, formatRow(d.D[i], "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTSV writes the matrix with a header row of names.
+func (d *Distances) WriteTSV(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "\t%s\n", strings.Join(d.Names, "\t")); err != nil {
+		return err
+	}
+	for i, name := range d.Names {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", name, formatRow(d.D[i], "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatRow(row []float64, sep string) string {
+	fields := make([]string, len(row))
+	for i, v := range row {
+		fields[i] = fmt.Sprintf("%.6g", v)
+	}
+	return strings.Join(fields, sep)
+}
@@ -0,0 +1,127 @@
+package phylo
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tree is a node of a phylogenetic tree. Leaves are named samples; Length is
+// the branch length to the parent.
+type Tree struct {
+	Name     string
+	Length   float64
+	Children []*Tree
+}
+
+// NeighborJoining builds an unrooted tree by the method of Saitou & Nei
+// (1987), returned with a trifurcating root. Negative branch lengths are set
+// to zero.
+func NeighborJoining(d *Distances) *Tree {
+	n := len(d.Names)
+	nodes := make([]*Tree, n)
+	dist := make([][]float64, n)
+	for i := range nodes {
+		nodes[i] = &Tree{Name: d.Names[i]}
+		dist[i] = append([]float64{}, d.D[i]...)
+	}
+	switch n {
+	case 0:
+		return &Tree{}
+	case 1:
+		return nodes[0]
+	}
+
+	for len(nodes) > 3 {
+		m := len(nodes)
+		r := make([]float64, m)
+		for i := range nodes {
+			for j := range nodes {
+				r[i] += dist[i][j]
+			}
+		}
+		a, b := 0, 1
+		best := 0.0
+		for i := 0; i < m; i++ {
+			for j := i + 1; j < m; j++ {
+				q := float64(m-2)*dist[i][j] - r[i] - r[j]
+				if (i == 0 && j == 1) || q < best {
+					a, b, best = i, j, q
+				}
+			}
+		}
+
+		la := dist[a][b]/2 + (r[a]-r[b])/float64(2*(m-2))
+		lb := dist[a][b] - la
+		nodes[a].Length = nonNegative(la)
+		nodes[b].Length = nonNegative(lb)
+		joined := &Tree{Children: []*Tree{nodes[a], nodes[b]}}
+
+		// The joined node replaces a, and b is removed.
+		for k := 0; k < m; k++ {
+			if k != a && k != b {
+				dk := (dist[a][k] + dist[b][k] - dist[a][b]) / 2
+				dist[a][k] = dk
+				dist[k][a] = dk
+			}
+		}
+		dist[a][a] = 0
+		nodes[a] = joined
+		nodes = append(nodes[:b], nodes[b+1:]...)
+		dist = append(dist[:b], dist[b+1:]...)
+		for k := range dist {
+			dist[k] = append(dist[k][:b], dist[k][b+1:]...)
+		}
+	}
+
+	if len(nodes) == 2 {
+		nodes[0].Length = nonNegative(dist[0][1] / 2)
+		nodes[1].Length = nonNegative(dist[0][1] / 2)
+		return &Tree{Children: nodes}
+	}
+	nodes[0].Length = nonNegative((dist[0][1] + dist[0][2] - dist[1][2]) / 2)
+	nodes[1].Length = nonNegative((dist[0][1] + dist[1][2] - dist[0][2]) / 2)
+	nodes[2].Length = nonNegative((dist[0][2] + dist[1][2] - dist[0][1]) / 2)
+	return &Tree{Children: nodes}
+}
+
+func nonNegative(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	return x
+}
+
+// WriteNewick writes the tree in Newick format, quoting names with characters
+// Newick reserves.
+func (t *Tree) WriteNewick(w io.Writer) error {
+	var b strings.Builder
+	t.newick(&b, true)
+	b.WriteString(";\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (t *Tree) newick(b *strings.Builder, root bool) {
+	if len(t.Children) > 0 {
+		b.WriteString("(")
+		for i, c := range t.Children {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			c.newick(b, false)
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(newickName(t.Name))
+	if !root {
+		fmt.Fprintf(b, ":%.6g", t.Length)
+	}
+}
+
+func newickName(name string) string {
+	if strings.ContainsAny(name, "()[]':;, \t") {
+		return "'" + strings.Replace(name, "'", "''", -1) + "'"
+	}
+	return name
+}
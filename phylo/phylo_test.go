@@ -0,0 +1,68 @@
+package phylo
+
+import (
+	"os"
+
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+func ExampleDistances_WritePHYLIP() {
+	d := NewDistances([]string{"a", "b", "c"})
+	d.Set(0, 1, 0.01)
+	d.Set(0, 2, 0.125)
+	d.Set(1, 2, 0.1)
+	d.WritePHYLIP(os.Stdout)
+	d.WriteTSV(os.Stdout)
+	// Output:
+	// 3
+	// a	0	0.01	0.125
+	// b	0.01	0	0.1
+	// c	0.125	0.1	0
+	// 	a	b	c
+	// a	0	0.01	0.125
+	// b	0.01	0	0.1
+	// c	0.125	0.1	0
+}
+
+func ExampleNeighborJoining() {
+	// The example of Saitou & Nei (1987), as given on Wikipedia.
+	d := NewDistances([]string{"a", "b", "c", "d", "e's"})
+	for _, e := range []struct {
+		i, j int
+		d    float64
+	}{
+		{0, 1, 5}, {0, 2, 9}, {0, 3, 9}, {0, 4, 8},
+		{1, 2, 10}, {1, 3, 10}, {1, 4, 9},
+		{2, 3, 8}, {2, 4, 7},
+		{3, 4, 3},
+	} {
+		d.Set(e.i, e.j, e.d)
+	}
+	NeighborJoining(d).WriteNewick(os.Stdout)
+	// Output:
+	// (((a:2,b:3):3,c:4):2,d:2,'e''s':1);
+}
+
+func ExampleJaccardDistances() {
+	idx := pangenome.NewIndex(5)
+	idx.AddGenome("a", []string{">a"}, []string{"GATTACAGCGTCCATGG"})
+	idx.AddGenome("b", []string{">b"}, []string{"GATTACAGCGTCCATGGTT"})
+	idx.AddGenome("c", []string{">c"}, []string{"GATTACAGCTTCCATGG"})
+
+	JaccardDistances(idx).WriteTSV(os.Stdout)
+	EdgeJaccardDistances(idx).WriteTSV(os.Stdout)
+	AccessoryDistances(idx, idx.NodeUnitigs(), pangenome.DefaultThresholds).WriteTSV(os.Stdout)
+	// Output:
+	// 	a	b	c
+	// a	0	0.133333	0.555556
+	// b	0.133333	0	0.6
+	// c	0.555556	0.6	0
+	// 	a	b	c
+	// a	0	0.142857	0.666667
+	// b	0.142857	0	0.7
+	// c	0.666667	0.7	0
+	// 	a	b	c
+	// a	0	0.166667	0.833333
+	// b	0.166667	0	1
+	// c	0.833333	1	0
+}
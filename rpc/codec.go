@@ -0,0 +1,25 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// Codec carries messages as JSON, for clients without protobuf support.
+// They select it with the json content-subtype, grpc.CallContentSubtype in
+// Go; otherwise messages travel as protobuf.
+type Codec struct{}
+
+// Name is the gRPC content-subtype of the codec.
+func (Codec) Name() string { return "json" }
+
+// Marshal encodes a message.
+func (Codec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal decodes a message.
+func (Codec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+func init() {
+	encoding.RegisterCodec(Codec{})
+}
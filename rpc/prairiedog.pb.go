@@ -0,0 +1,685 @@
+// The prairiedog pangenome service. Clients call it over plain protobuf.
+// Clients without protobuf support can instead set the content-subtype to
+// json and send messages as JSON, with field names as below.
+//
+// prairiedog.pb.go and prairiedog_grpc.pb.go are generated from this file:
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	    --go-grpc_out=. --go-grpc_opt=paths=source_relative prairiedog.proto
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: prairiedog.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SamplesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SamplesRequest) Reset() {
+	*x = SamplesRequest{}
+	mi := &file_prairiedog_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SamplesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplesRequest) ProtoMessage() {}
+
+func (x *SamplesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplesRequest.ProtoReflect.Descriptor instead.
+func (*SamplesRequest) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{0}
+}
+
+type SamplesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Samples       []string               `protobuf:"bytes,1,rep,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SamplesReply) Reset() {
+	*x = SamplesReply{}
+	mi := &file_prairiedog_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SamplesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplesReply) ProtoMessage() {}
+
+func (x *SamplesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplesReply.ProtoReflect.Descriptor instead.
+func (*SamplesReply) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SamplesReply) GetSamples() []string {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type ContigChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sample        string                 `protobuf:"bytes,1,opt,name=sample,proto3" json:"sample,omitempty"`
+	Header        string                 `protobuf:"bytes,2,opt,name=header,proto3" json:"header,omitempty"`
+	Sequence      string                 `protobuf:"bytes,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ContigChunk) Reset() {
+	*x = ContigChunk{}
+	mi := &file_prairiedog_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContigChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContigChunk) ProtoMessage() {}
+
+func (x *ContigChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContigChunk.ProtoReflect.Descriptor instead.
+func (*ContigChunk) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ContigChunk) GetSample() string {
+	if x != nil {
+		return x.Sample
+	}
+	return ""
+}
+
+func (x *ContigChunk) GetHeader() string {
+	if x != nil {
+		return x.Header
+	}
+	return ""
+}
+
+func (x *ContigChunk) GetSequence() string {
+	if x != nil {
+		return x.Sequence
+	}
+	return ""
+}
+
+type SampleReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sample        string                 `protobuf:"bytes,1,opt,name=sample,proto3" json:"sample,omitempty"`
+	Contigs       int64                  `protobuf:"varint,2,opt,name=contigs,proto3" json:"contigs,omitempty"`
+	Bases         int64                  `protobuf:"varint,3,opt,name=bases,proto3" json:"bases,omitempty"`
+	Kmers         int64                  `protobuf:"varint,4,opt,name=kmers,proto3" json:"kmers,omitempty"`
+	Novel         int64                  `protobuf:"varint,5,opt,name=novel,proto3" json:"novel,omitempty"`
+	Skipped       []string               `protobuf:"bytes,6,rep,name=skipped,proto3" json:"skipped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SampleReport) Reset() {
+	*x = SampleReport{}
+	mi := &file_prairiedog_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SampleReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SampleReport) ProtoMessage() {}
+
+func (x *SampleReport) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SampleReport.ProtoReflect.Descriptor instead.
+func (*SampleReport) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SampleReport) GetSample() string {
+	if x != nil {
+		return x.Sample
+	}
+	return ""
+}
+
+func (x *SampleReport) GetContigs() int64 {
+	if x != nil {
+		return x.Contigs
+	}
+	return 0
+}
+
+func (x *SampleReport) GetBases() int64 {
+	if x != nil {
+		return x.Bases
+	}
+	return 0
+}
+
+func (x *SampleReport) GetKmers() int64 {
+	if x != nil {
+		return x.Kmers
+	}
+	return 0
+}
+
+func (x *SampleReport) GetNovel() int64 {
+	if x != nil {
+		return x.Novel
+	}
+	return 0
+}
+
+func (x *SampleReport) GetSkipped() []string {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+type IngestReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Samples       []*SampleReport        `protobuf:"bytes,1,rep,name=samples,proto3" json:"samples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestReport) Reset() {
+	*x = IngestReport{}
+	mi := &file_prairiedog_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestReport) ProtoMessage() {}
+
+func (x *IngestReport) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestReport.ProtoReflect.Descriptor instead.
+func (*IngestReport) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *IngestReport) GetSamples() []*SampleReport {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+type TraverseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kmer  string                 `protobuf:"bytes,1,opt,name=kmer,proto3" json:"kmer,omitempty"`
+	// forward, reverse or both.
+	Direction     string `protobuf:"bytes,2,opt,name=direction,proto3" json:"direction,omitempty"`
+	Depth         int32  `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	Limit         int32  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraverseRequest) Reset() {
+	*x = TraverseRequest{}
+	mi := &file_prairiedog_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraverseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraverseRequest) ProtoMessage() {}
+
+func (x *TraverseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraverseRequest.ProtoReflect.Descriptor instead.
+func (*TraverseRequest) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TraverseRequest) GetKmer() string {
+	if x != nil {
+		return x.Kmer
+	}
+	return ""
+}
+
+func (x *TraverseRequest) GetDirection() string {
+	if x != nil {
+		return x.Direction
+	}
+	return ""
+}
+
+func (x *TraverseRequest) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *TraverseRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type TraversalStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uid           uint64                 `protobuf:"varint,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Sequence      string                 `protobuf:"bytes,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Depth         int32                  `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	From          uint64                 `protobuf:"varint,4,opt,name=from,proto3" json:"from,omitempty"`
+	Weight        int64                  `protobuf:"varint,5,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TraversalStep) Reset() {
+	*x = TraversalStep{}
+	mi := &file_prairiedog_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TraversalStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraversalStep) ProtoMessage() {}
+
+func (x *TraversalStep) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraversalStep.ProtoReflect.Descriptor instead.
+func (*TraversalStep) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TraversalStep) GetUid() uint64 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *TraversalStep) GetSequence() string {
+	if x != nil {
+		return x.Sequence
+	}
+	return ""
+}
+
+func (x *TraversalStep) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *TraversalStep) GetFrom() uint64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *TraversalStep) GetWeight() int64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type PathsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	K             int32                  `protobuf:"varint,3,opt,name=k,proto3" json:"k,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PathsRequest) Reset() {
+	*x = PathsRequest{}
+	mi := &file_prairiedog_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PathsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PathsRequest) ProtoMessage() {}
+
+func (x *PathsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PathsRequest.ProtoReflect.Descriptor instead.
+func (*PathsRequest) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PathsRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *PathsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *PathsRequest) GetK() int32 {
+	if x != nil {
+		return x.K
+	}
+	return 0
+}
+
+type Path struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []uint64               `protobuf:"varint,1,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	Sequence      string                 `protobuf:"bytes,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Probabilities []float64              `protobuf:"fixed64,3,rep,packed,name=probabilities,proto3" json:"probabilities,omitempty"`
+	Probability   float64                `protobuf:"fixed64,4,opt,name=probability,proto3" json:"probability,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Path) Reset() {
+	*x = Path{}
+	mi := &file_prairiedog_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Path) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Path) ProtoMessage() {}
+
+func (x *Path) ProtoReflect() protoreflect.Message {
+	mi := &file_prairiedog_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Path.ProtoReflect.Descriptor instead.
+func (*Path) Descriptor() ([]byte, []int) {
+	return file_prairiedog_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Path) GetNodes() []uint64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *Path) GetSequence() string {
+	if x != nil {
+		return x.Sequence
+	}
+	return ""
+}
+
+func (x *Path) GetProbabilities() []float64 {
+	if x != nil {
+		return x.Probabilities
+	}
+	return nil
+}
+
+func (x *Path) GetProbability() float64 {
+	if x != nil {
+		return x.Probability
+	}
+	return 0
+}
+
+var File_prairiedog_proto protoreflect.FileDescriptor
+
+const file_prairiedog_proto_rawDesc = "" +
+	"\n" +
+	"\x10prairiedog.proto\x12\n" +
+	"prairiedog\"\x10\n" +
+	"\x0eSamplesRequest\"(\n" +
+	"\fSamplesReply\x12\x18\n" +
+	"\asamples\x18\x01 \x03(\tR\asamples\"Y\n" +
+	"\vContigChunk\x12\x16\n" +
+	"\x06sample\x18\x01 \x01(\tR\x06sample\x12\x16\n" +
+	"\x06header\x18\x02 \x01(\tR\x06header\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\tR\bsequence\"\x9c\x01\n" +
+	"\fSampleReport\x12\x16\n" +
+	"\x06sample\x18\x01 \x01(\tR\x06sample\x12\x18\n" +
+	"\acontigs\x18\x02 \x01(\x03R\acontigs\x12\x14\n" +
+	"\x05bases\x18\x03 \x01(\x03R\x05bases\x12\x14\n" +
+	"\x05kmers\x18\x04 \x01(\x03R\x05kmers\x12\x14\n" +
+	"\x05novel\x18\x05 \x01(\x03R\x05novel\x12\x18\n" +
+	"\askipped\x18\x06 \x03(\tR\askipped\"B\n" +
+	"\fIngestReport\x122\n" +
+	"\asamples\x18\x01 \x03(\v2\x18.prairiedog.SampleReportR\asamples\"o\n" +
+	"\x0fTraverseRequest\x12\x12\n" +
+	"\x04kmer\x18\x01 \x01(\tR\x04kmer\x12\x1c\n" +
+	"\tdirection\x18\x02 \x01(\tR\tdirection\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\x05R\x05depth\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\x7f\n" +
+	"\rTraversalStep\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\x04R\x03uid\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\tR\bsequence\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\x05R\x05depth\x12\x12\n" +
+	"\x04from\x18\x04 \x01(\x04R\x04from\x12\x16\n" +
+	"\x06weight\x18\x05 \x01(\x03R\x06weight\"@\n" +
+	"\fPathsRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\f\n" +
+	"\x01k\x18\x03 \x01(\x05R\x01k\"\x80\x01\n" +
+	"\x04Path\x12\x14\n" +
+	"\x05nodes\x18\x01 \x03(\x04R\x05nodes\x12\x1a\n" +
+	"\bsequence\x18\x02 \x01(\tR\bsequence\x12$\n" +
+	"\rprobabilities\x18\x03 \x03(\x01R\rprobabilities\x12 \n" +
+	"\vprobability\x18\x04 \x01(\x01R\vprobability2\x8b\x02\n" +
+	"\tPangenome\x12?\n" +
+	"\aSamples\x12\x1a.prairiedog.SamplesRequest\x1a\x18.prairiedog.SamplesReply\x12@\n" +
+	"\tAddGenome\x12\x17.prairiedog.ContigChunk\x1a\x18.prairiedog.IngestReport(\x01\x12D\n" +
+	"\bTraverse\x12\x1b.prairiedog.TraverseRequest\x1a\x19.prairiedog.TraversalStep0\x01\x125\n" +
+	"\x05Paths\x12\x18.prairiedog.PathsRequest\x1a\x10.prairiedog.Path0\x01B$Z\"github.com/superphy/prairiedog/rpcb\x06proto3"
+
+var (
+	file_prairiedog_proto_rawDescOnce sync.Once
+	file_prairiedog_proto_rawDescData []byte
+)
+
+func file_prairiedog_proto_rawDescGZIP() []byte {
+	file_prairiedog_proto_rawDescOnce.Do(func() {
+		file_prairiedog_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_prairiedog_proto_rawDesc), len(file_prairiedog_proto_rawDesc)))
+	})
+	return file_prairiedog_proto_rawDescData
+}
+
+var file_prairiedog_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_prairiedog_proto_goTypes = []any{
+	(*SamplesRequest)(nil),  // 0: prairiedog.SamplesRequest
+	(*SamplesReply)(nil),    // 1: prairiedog.SamplesReply
+	(*ContigChunk)(nil),     // 2: prairiedog.ContigChunk
+	(*SampleReport)(nil),    // 3: prairiedog.SampleReport
+	(*IngestReport)(nil),    // 4: prairiedog.IngestReport
+	(*TraverseRequest)(nil), // 5: prairiedog.TraverseRequest
+	(*TraversalStep)(nil),   // 6: prairiedog.TraversalStep
+	(*PathsRequest)(nil),    // 7: prairiedog.PathsRequest
+	(*Path)(nil),            // 8: prairiedog.Path
+}
+var file_prairiedog_proto_depIdxs = []int32{
+	3, // 0: prairiedog.IngestReport.samples:type_name -> prairiedog.SampleReport
+	0, // 1: prairiedog.Pangenome.Samples:input_type -> prairiedog.SamplesRequest
+	2, // 2: prairiedog.Pangenome.AddGenome:input_type -> prairiedog.ContigChunk
+	5, // 3: prairiedog.Pangenome.Traverse:input_type -> prairiedog.TraverseRequest
+	7, // 4: prairiedog.Pangenome.Paths:input_type -> prairiedog.PathsRequest
+	1, // 5: prairiedog.Pangenome.Samples:output_type -> prairiedog.SamplesReply
+	4, // 6: prairiedog.Pangenome.AddGenome:output_type -> prairiedog.IngestReport
+	6, // 7: prairiedog.Pangenome.Traverse:output_type -> prairiedog.TraversalStep
+	8, // 8: prairiedog.Pangenome.Paths:output_type -> prairiedog.Path
+	5, // [5:9] is the sub-list for method output_type
+	1, // [1:5] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_prairiedog_proto_init() }
+func file_prairiedog_proto_init() {
+	if File_prairiedog_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_prairiedog_proto_rawDesc), len(file_prairiedog_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_prairiedog_proto_goTypes,
+		DependencyIndexes: file_prairiedog_proto_depIdxs,
+		MessageInfos:      file_prairiedog_proto_msgTypes,
+	}.Build()
+	File_prairiedog_proto = out.File
+	file_prairiedog_proto_goTypes = nil
+	file_prairiedog_proto_depIdxs = nil
+}
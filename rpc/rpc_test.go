@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+
+	"github.com/superphy/prairiedog/pangenome"
+	"google.golang.org/grpc"
+)
+
+func ExampleServer() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	opts := pangenome.DefaultOptions
+	opts.Dir = dir
+	opts.Embedded = true
+	opts.K = 5
+	g, err := pangenome.Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s := grpc.NewServer()
+	RegisterPangenomeServer(s, New(g, Options{}))
+	go s.Serve(lis)
+	defer s.Stop()
+
+	cc, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer cc.Close()
+	client := NewPangenomeClient(cc)
+	ctx := context.Background()
+
+	stream, err := client.AddGenome(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, chunk := range []*ContigChunk{
+		{Sample: "a", Header: "a1", Sequence: "GATTACA"},
+		{Sample: "a", Header: "a1", Sequence: "GCGTCCATGG"},
+		{Sample: "b", Header: "b1", Sequence: "GATTACAGCTTCCATGG"},
+		{Sample: "b", Header: "b2", Sequence: "ACGT"},
+	} {
+		if err := stream.Send(chunk); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	report, err := stream.CloseAndRecv()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range report.Samples {
+		fmt.Printf("%s: %v contigs, %v bases, %v k-mers, %v novel, skipped %v\n",
+			r.Sample, r.Contigs, r.Bases, r.Kmers, r.Novel, r.Skipped)
+	}
+
+	samples, err := client.Samples(ctx, &SamplesRequest{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(samples.Samples)
+
+	steps, err := client.Traverse(ctx, &TraverseRequest{Kmer: "ACAGC", Depth: 2})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for {
+		step, err := steps.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Println(step.Depth, step.Sequence, step.Weight)
+	}
+
+	paths, err := client.Paths(ctx, &PathsRequest{From: "TACAG", To: "CCATG", K: 2})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for {
+		p, err := paths.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("%s %.2f\n", p.Sequence, p.Probability)
+	}
+
+	// Clients without protobuf can send JSON instead.
+	samples, err = client.Samples(ctx, &SamplesRequest{}, grpc.CallContentSubtype(Codec{}.Name()))
+	fmt.Println(samples.Samples, err)
+
+	stream, err = client.AddGenome(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stream.Send(&ContigChunk{Sample: "a", Header: "a1", Sequence: "GATTACA"})
+	_, err = stream.CloseAndRecv()
+	fmt.Println(err)
+
+	stream, err = client.AddGenome(ctx)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stream.Send(&ContigChunk{Sample: "c", Header: "c1", Sequence: "GATTACA"})
+	stream.Send(&ContigChunk{Sample: "d", Header: "d1", Sequence: "GATTACA"})
+	stream.Send(&ContigChunk{Sample: "c", Header: "c2", Sequence: "GATTACA"})
+	_, err = stream.CloseAndRecv()
+	fmt.Println(err)
+	// Output:
+	// a: 1 contigs, 17 bases, 13 k-mers, 13 novel, skipped []
+	// b: 2 contigs, 21 bases, 13 k-mers, 5 novel, skipped [>b2]
+	// [a b]
+	// 0 ACAGC 0
+	// 1 CAGCG 1
+	// 1 CAGCT 1
+	// 2 AGCGT 1
+	// 2 AGCTT 1
+	// TACAGCGTCCATG 0.50
+	// TACAGCTTCCATG 0.50
+	// [a b] <nil>
+	// rpc error: code = AlreadyExists desc = sample a already exists
+	// rpc error: code = InvalidArgument desc = contigs of c were sent apart; send each sample's contigs together
+}
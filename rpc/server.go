@@ -0,0 +1,306 @@
+// Package rpc serves the pangenome over gRPC, with streaming ingestion of
+// genomes and streaming traversals. The service is defined in
+// prairiedog.proto and carried as protobuf, or as JSON for clients that ask.
+package rpc
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options configure a Server.
+type Options struct {
+	ReadOnly bool // reject AddGenome calls.
+}
+
+// Server implements PangenomeServer over a graph. Queries run over an index
+// loaded from the graph on first use and reloaded after every write.
+type Server struct {
+	UnimplementedPangenomeServer
+
+	g     *pangenome.Graph
+	opts  Options
+	cache *pangenome.IndexCache
+}
+
+// New creates a service for a graph.
+func New(g *pangenome.Graph, opts Options) *Server {
+	return &Server{
+		g:     g,
+		opts:  opts,
+		cache: pangenome.NewIndexCache(g),
+	}
+}
+
+// index returns the loaded index, loading it if needed.
+func (s *Server) index() (*pangenome.Index, error) {
+	idx, err := s.cache.Index()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return idx, nil
+}
+
+// Samples lists the samples in the graph.
+func (s *Server) Samples(ctx context.Context, in *SamplesRequest) (*SamplesReply, error) {
+	idx, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	return &SamplesReply{Samples: append([]string{}, idx.Samples...)}, nil
+}
+
+// genome is a sample's contigs as they arrive.
+type genome struct {
+	headers   []string
+	sequences []*strings.Builder
+}
+
+// AddGenome adds each streamed sample as soon as the client moves on to the
+// next, so only one genome is held in memory; a sample's contigs must be sent
+// together. If a sample fails, those before it stay in the graph.
+func (s *Server) AddGenome(stream Pangenome_AddGenomeServer) error {
+	if s.opts.ReadOnly {
+		return status.Error(codes.PermissionDenied, "server is read-only")
+	}
+
+	report := &IngestReport{}
+	started := make(map[string]bool)
+	var name string
+	var gen *genome
+	add := func() error {
+		if gen == nil {
+			return nil
+		}
+		return s.cache.Update(func() error {
+			r, err := s.ingest(stream.Context(), name, gen)
+			if err != nil {
+				return err
+			}
+			report.Samples = append(report.Samples, r)
+			return nil
+		})
+	}
+
+	var last *ContigChunk
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if chunk.Sample == "" || chunk.Header == "" {
+			return status.Error(codes.InvalidArgument, "chunk is missing its sample or header")
+		}
+		if !strings.HasPrefix(chunk.Header, ">") {
+			chunk.Header = ">" + chunk.Header
+		}
+		if chunk.Sample != name {
+			if started[chunk.Sample] {
+				return status.Errorf(codes.InvalidArgument, "contigs of %s were sent apart; send each sample's contigs together", chunk.Sample)
+			}
+			if err := add(); err != nil {
+				return err
+			}
+			name, gen = chunk.Sample, &genome{}
+			started[name] = true
+		}
+		if last == nil || last.Sample != chunk.Sample || last.Header != chunk.Header {
+			for _, h := range gen.headers {
+				if h == chunk.Header {
+					return status.Errorf(codes.InvalidArgument, "contig %s of %s was sent twice", chunk.Header, chunk.Sample)
+				}
+			}
+			gen.headers = append(gen.headers, chunk.Header)
+			gen.sequences = append(gen.sequences, &strings.Builder{})
+		}
+		gen.sequences[len(gen.sequences)-1].WriteString(strings.ToUpper(strings.TrimSpace(chunk.Sequence)))
+		last = chunk
+	}
+	if gen == nil {
+		return status.Error(codes.InvalidArgument, "no contigs were sent")
+	}
+	if err := add(); err != nil {
+		return err
+	}
+	return stream.SendAndClose(report)
+}
+
+// ingest adds one sample to the graph and reports on it.
+func (s *Server) ingest(ctx context.Context, name string, gen *genome) (*SampleReport, error) {
+	exists, err := s.g.HasSample(name)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if exists {
+		return nil, status.Errorf(codes.AlreadyExists, "sample %s already exists", name)
+	}
+
+	r := &SampleReport{Sample: name}
+	sequences := make([]string, len(gen.sequences))
+	seen := make(map[string]bool)
+	var distinct []string
+	for i, b := range gen.sequences {
+		seq := b.String()
+		sequences[i] = seq
+		r.Contigs++
+		r.Bases += int64(len(seq))
+		// Contigs no longer than k have no edges, so they're skipped.
+		if len(seq) <= s.g.K {
+			r.Skipped = append(r.Skipped, gen.headers[i])
+			continue
+		}
+		for j := 0; j+s.g.K <= len(seq); j++ {
+			if kmer := seq[j : j+s.g.K]; !seen[kmer] {
+				seen[kmer] = true
+				distinct = append(distinct, kmer)
+			}
+		}
+	}
+	if len(distinct) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "sample %s has no contigs longer than k=%v", name, s.g.K)
+	}
+	found, err := s.g.LookupKmers(distinct)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	r.Kmers = int64(len(distinct))
+	r.Novel = int64(len(distinct) - len(found))
+
+	km := kmers.NewFromSequences(gen.headers, sequences)
+	km.K = s.g.K
+	if _, err := s.g.AddGenome(name, km, ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return r, nil
+}
+
+// Traverse walks breadth first from a k-mer along forward edges, reverse
+// edges or both, sending each node the first time it's reached. Neighbours
+// are visited in uid order. A depth or limit of zero is unbounded.
+func (s *Server) Traverse(in *TraverseRequest, stream Pangenome_TraverseServer) error {
+	var directions []func(*pangenome.Index, uint64) map[uint64]int
+	forward := func(idx *pangenome.Index, u uint64) map[uint64]int { return idx.Forward[u] }
+	reverse := func(idx *pangenome.Index, u uint64) map[uint64]int { return idx.Reverse[u] }
+	switch in.Direction {
+	case "", "forward":
+		directions = append(directions, forward)
+	case "reverse":
+		directions = append(directions, reverse)
+	case "both":
+		directions = append(directions, forward, reverse)
+	default:
+		return status.Errorf(codes.InvalidArgument, "direction must be forward, reverse or both, not %s", in.Direction)
+	}
+	if in.Depth < 0 || in.Limit < 0 {
+		return status.Error(codes.InvalidArgument, "depth and limit must not be negative")
+	}
+	idx, err := s.index()
+	if err != nil {
+		return err
+	}
+	start, ok := idx.Lookup(strings.ToUpper(in.Kmer))
+	if !ok {
+		return status.Errorf(codes.NotFound, "k-mer %s is not in the graph", in.Kmer)
+	}
+
+	ctx := stream.Context()
+	seen := map[uint64]bool{start: true}
+	level := []uint64{start}
+	sent := int32(0)
+	send := func(step *TraversalStep) error {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		sent++
+		return stream.Send(step)
+	}
+	if err := send(&TraversalStep{Uid: start, Sequence: idx.Sequences[start]}); err != nil {
+		return err
+	}
+	for depth := int32(1); len(level) > 0 && (in.Depth == 0 || depth <= in.Depth); depth++ {
+		var next []uint64
+		for _, u := range level {
+			for _, dir := range directions {
+				edges := dir(idx, u)
+				uids := make([]uint64, 0, len(edges))
+				for v := range edges {
+					uids = append(uids, v)
+				}
+				sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+				for _, v := range uids {
+					if seen[v] {
+						continue
+					}
+					if in.Limit > 0 && sent >= in.Limit {
+						return nil
+					}
+					seen[v] = true
+					next = append(next, v)
+					step := &TraversalStep{
+						Uid:      v,
+						Sequence: idx.Sequences[v],
+						Depth:    depth,
+						From:     u,
+						Weight:   int64(edges[v]),
+					}
+					if err := send(step); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// Paths sends the k most probable paths between two k-mers, most probable
+// first. k defaults to 3.
+func (s *Server) Paths(in *PathsRequest, stream Pangenome_PathsServer) error {
+	if in.From == "" || in.To == "" {
+		return status.Error(codes.InvalidArgument, "missing from or to")
+	}
+	if in.K < 0 {
+		return status.Error(codes.InvalidArgument, "k must not be negative")
+	}
+	k := 3
+	if in.K > 0 {
+		k = int(in.K)
+	}
+	idx, err := s.index()
+	if err != nil {
+		return err
+	}
+	paths, err := idx.KBestPaths(strings.ToUpper(in.From), strings.ToUpper(in.To), k)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	for _, p := range paths {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		msg := &Path{
+			Nodes:         p.Nodes,
+			Sequence:      p.Sequence,
+			Probabilities: p.Probabilities,
+			Probability:   p.Probability,
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,510 @@
+// Package server exposes the pangenome over HTTP with JSON responses, so it
+// can be queried without linking Go code or talking to Dgraph.
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// Options configure a Server.
+type Options struct {
+	ReadOnly bool          // reject requests which change the graph.
+	Timeout  time.Duration // longest a request may take.
+	PageSize int           // items per page when no limit is given.
+	MaxPage  int           // largest limit a request may ask for.
+	MaxBody  int64         // largest genome that may be added, in bytes.
+}
+
+// DefaultOptions allow writes and bound requests to a minute.
+var DefaultOptions = Options{
+	Timeout:  time.Minute,
+	PageSize: 100,
+	MaxPage:  10000,
+	MaxBody:  1 << 30,
+}
+
+// Server answers queries against a graph. Queries run over an index loaded
+// from the graph on first use and reloaded after every write.
+type Server struct {
+	g     *pangenome.Graph
+	opts  Options
+	cache *pangenome.IndexCache
+}
+
+// New creates a server for a graph.
+func New(g *pangenome.Graph, opts Options) *Server {
+	return &Server{
+		g:     g,
+		opts:  opts,
+		cache: pangenome.NewIndexCache(g),
+	}
+}
+
+// Handler routes every endpoint:
+//
+//	GET  /samples                  sample names, paginated
+//	POST /samples?name=<name>      add a genome from a FASTA body
+//	GET  /nodes                    every node, paginated
+//	GET  /nodes/<kmer>             a node and the samples containing it
+//	GET  /nodes/<kmer>/neighbours  successors and predecessors with weights
+//	GET  /locate?seq=<sequence>    where a sequence sits in the pangenome
+//	GET  /paths?from=<kmer>&to=<kmer>&k=<n>
+//	                               shortest and k most probable paths
+//	GET  /stats                    summary statistics
+//	GET  /subgraph?kmer=<kmer>&radius=<n>&format=json|gfa
+//	                               the nodes within radius edges of a k-mer
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/samples", s.samples)
+	mux.HandleFunc("/nodes", s.nodes)
+	mux.HandleFunc("/nodes/", s.node)
+	mux.HandleFunc("/locate", s.locate)
+	mux.HandleFunc("/paths", s.paths)
+	mux.HandleFunc("/stats", s.stats)
+	mux.HandleFunc("/subgraph", s.subgraph)
+	return http.TimeoutHandler(mux, s.opts.Timeout, `{"error":"request timed out"}`)
+}
+
+// Page is a slice of a longer list.
+type Page struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Items  interface{} `json:"items"`
+}
+
+// page reads offset and limit, returning the bounds of the page of n items.
+func (s *Server) page(r *http.Request, n int) (int, int, error) {
+	offset, limit := 0, s.opts.PageSize
+	if v := r.URL.Query().Get("offset"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			return 0, 0, fmt.Errorf("bad offset %q", v)
+		}
+		offset = o
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 || l > s.opts.MaxPage {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %v", s.opts.MaxPage)
+		}
+		limit = l
+	}
+	if offset > n {
+		offset = n
+	}
+	end := offset + limit
+	if end > n {
+		end = n
+	}
+	return offset, end, nil
+}
+
+func (s *Server) samples(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		idx, err := s.cache.Index()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		start, end, err := s.page(r, len(idx.Samples))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, Page{len(idx.Samples), start, end - start, idx.Samples[start:end]})
+	case http.MethodPost:
+		s.addSample(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method))
+	}
+}
+
+func (s *Server) addSample(w http.ResponseWriter, r *http.Request) {
+	if s.opts.ReadOnly {
+		writeError(w, http.StatusForbidden, fmt.Errorf("server is read-only"))
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing name"))
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, s.opts.MaxBody))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("genome is larger than %v bytes", s.opts.MaxBody))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	headers, sequences := parseFasta(string(body))
+	if len(sequences) == 0 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no sequences in body"))
+		return
+	}
+
+	code := http.StatusInternalServerError
+	err = s.cache.Update(func() error {
+		exists, err := s.g.HasSample(name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			code = http.StatusConflict
+			return fmt.Errorf("sample %s already exists", name)
+		}
+		km := kmers.NewFromSequences(headers, sequences)
+		km.K = s.g.K
+		_, err = s.g.AddGenome(name, km, r.Context())
+		if err != nil && r.Context().Err() != nil {
+			code = statusClientClosedRequest
+		}
+		return err
+	})
+	if err != nil {
+		writeError(w, code, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"sample": name})
+}
+
+// statusClientClosedRequest is nginx's status for a request the client gave up
+// on, which net/http has no name for.
+const statusClientClosedRequest = 499
+
+// parseFasta splits FASTA text into headers and upper-cased sequences.
+func parseFasta(text string) ([]string, []string) {
+	var headers, sequences []string
+	var seq strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ">") {
+			if len(headers) > 0 {
+				sequences = append(sequences, seq.String())
+				seq.Reset()
+			}
+			headers = append(headers, line)
+			continue
+		}
+		seq.WriteString(strings.ToUpper(line))
+	}
+	if len(headers) > 0 {
+		sequences = append(sequences, seq.String())
+	}
+	return headers, sequences
+}
+
+// Node is a k-mer node and the samples containing it.
+type Node struct {
+	UID      uint64   `json:"uid"`
+	Sequence string   `json:"sequence"`
+	Samples  []string `json:"samples"`
+}
+
+// Neighbour is a node joined to another by an edge of the given weight.
+type Neighbour struct {
+	UID      uint64 `json:"uid"`
+	Sequence string `json:"sequence"`
+	Weight   int    `json:"weight"`
+}
+
+// Neighbours are the successors and predecessors of a node.
+type Neighbours struct {
+	Forward []Neighbour `json:"forward"`
+	Reverse []Neighbour `json:"reverse"`
+}
+
+func newNode(idx *pangenome.Index, uid uint64) Node {
+	n := Node{
+		UID:      uid,
+		Sequence: idx.Sequences[uid],
+		Samples:  []string{},
+	}
+	for _, i := range idx.Colours[uid].Indices() {
+		n.Samples = append(n.Samples, idx.Samples[i])
+	}
+	return n
+}
+
+func neighbours(idx *pangenome.Index, edges map[uint64]int) []Neighbour {
+	ns := []Neighbour{}
+	for uid, weight := range edges {
+		ns = append(ns, Neighbour{uid, idx.Sequences[uid], weight})
+	}
+	sort.Slice(ns, func(i, j int) bool { return ns[i].UID < ns[j].UID })
+	return ns
+}
+
+func (s *Server) nodes(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	idx, err := s.cache.Index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	uids := idx.Nodes()
+	start, end, err := s.page(r, len(uids))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	items := make([]Node, 0, end-start)
+	for _, uid := range uids[start:end] {
+		items = append(items, newNode(idx, uid))
+	}
+	writeJSON(w, Page{len(uids), start, end - start, items})
+}
+
+func (s *Server) node(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/nodes/"), "/")
+	if len(parts) > 2 || (len(parts) == 2 && parts[1] != "neighbours") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+		return
+	}
+	idx, err := s.cache.Index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	kmer := strings.ToUpper(parts[0])
+	uid, ok := idx.Lookup(kmer)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("k-mer %s is not in the graph", kmer))
+		return
+	}
+	if len(parts) == 1 {
+		writeJSON(w, newNode(idx, uid))
+		return
+	}
+	writeJSON(w, Neighbours{
+		Forward: neighbours(idx, idx.Forward[uid]),
+		Reverse: neighbours(idx, idx.Reverse[uid]),
+	})
+}
+
+func (s *Server) locate(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	seq := strings.ToUpper(r.URL.Query().Get("seq"))
+	if seq == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing seq"))
+		return
+	}
+	idx, err := s.cache.Index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	run(w, r, func() (interface{}, error) {
+		return idx.Locate(seq), nil
+	})
+}
+
+// Paths are the shortest and most probable paths between two k-mers.
+type Paths struct {
+	Shortest *pangenome.Path   `json:"shortest"`
+	Best     []*pangenome.Path `json:"best"`
+}
+
+func (s *Server) paths(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	from, to := strings.ToUpper(q.Get("from")), strings.ToUpper(q.Get("to"))
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing from or to"))
+		return
+	}
+	k := 3
+	if v := q.Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > s.opts.MaxPage {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("k must be between 1 and %v", s.opts.MaxPage))
+			return
+		}
+		k = n
+	}
+	idx, err := s.cache.Index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	run(w, r, func() (interface{}, error) {
+		shortest, err := idx.ShortestPath(from, to)
+		if err != nil {
+			return nil, err
+		}
+		best, err := idx.KBestPaths(from, to, k)
+		if err != nil {
+			return nil, err
+		}
+		return Paths{shortest, best}, nil
+	})
+}
+
+func (s *Server) stats(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	run(w, r, func() (interface{}, error) {
+		return s.g.Stats()
+	})
+}
+
+// Edge is a weighted edge between two nodes.
+type Edge struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Weight int    `json:"weight"`
+}
+
+// Subgraph is a set of nodes and the edges between them.
+type Subgraph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+func (s *Server) subgraph(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	kmer := strings.ToUpper(q.Get("kmer"))
+	radius := 1
+	if v := q.Get("radius"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad radius %q", v))
+			return
+		}
+		radius = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "gfa" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, expected json or gfa", format))
+		return
+	}
+	idx, err := s.cache.Index()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	uid, ok := idx.Lookup(kmer)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("k-mer %s is not in the graph", kmer))
+		return
+	}
+
+	// Breadth first in both directions, up to radius edges away.
+	in := map[uint64]bool{uid: true}
+	frontier := []uint64{uid}
+	for d := 0; d < radius && len(frontier) > 0; d++ {
+		var next []uint64
+		for _, u := range frontier {
+			for _, edges := range []map[uint64]int{idx.Forward[u], idx.Reverse[u]} {
+				for v := range edges {
+					if !in[v] {
+						in[v] = true
+						next = append(next, v)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+	var unitigs []*pangenome.Unitig
+	for _, u := range idx.NodeUnitigs() {
+		if in[u.ID] {
+			unitigs = append(unitigs, u)
+		}
+	}
+
+	if format == "gfa" {
+		var buf bytes.Buffer
+		if err := pangenome.WriteGFA(&buf, idx, unitigs); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/x-gfa")
+		buf.WriteTo(w)
+		return
+	}
+	sub := Subgraph{Nodes: []Node{}, Edges: []Edge{}}
+	for _, u := range unitigs {
+		sub.Nodes = append(sub.Nodes, newNode(idx, u.ID))
+		for _, n := range neighbours(idx, idx.Forward[u.ID]) {
+			if in[n.UID] {
+				sub.Edges = append(sub.Edges, Edge{u.ID, n.UID, n.Weight})
+			}
+		}
+	}
+	writeJSON(w, sub)
+}
+
+// run answers with the result of fn unless the request's context ends first,
+// in which case the timeout handler has already answered. fn keeps running in
+// the background until it returns.
+func run(w http.ResponseWriter, r *http.Request, fn func() (interface{}, error)) {
+	type result struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		done <- result{v, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			writeError(w, http.StatusBadRequest, res.err)
+			return
+		}
+		writeJSON(w, res.v)
+	case <-r.Context().Done():
+	}
+}
+
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/superphy/prairiedog/kmers"
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+func get(url string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	fmt.Print(resp.StatusCode, " ", string(body))
+}
+
+func post(url string, body string) {
+	resp, err := http.Post(url, "text/plain", strings.NewReader(body))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	fmt.Print(resp.StatusCode, " ", string(b))
+}
+
+func ExampleServer() {
+	dir, err := ioutil.TempDir("", "prairiedog")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	opts := pangenome.DefaultOptions
+	opts.Dir = dir
+	opts.Embedded = true
+	opts.K = 5
+	g, err := pangenome.Open(opts)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer g.Close()
+	for _, genome := range []struct{ name, seq string }{
+		{"a", "GATTACAGCGTCCATGG"},
+		{"b", "GATTACAGCTTCCATGG"},
+	} {
+		km := kmers.NewFromSequences([]string{">" + genome.name}, []string{genome.seq})
+		km.K = g.K
+		if _, err := g.AddGenome(genome.name, km, context.Background()); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	sopts := DefaultOptions
+	sopts.ReadOnly = true
+	ts := httptest.NewServer(New(g, sopts).Handler())
+	defer ts.Close()
+
+	get(ts.URL + "/samples?offset=1&limit=5")
+	get(ts.URL + "/nodes?limit=2")
+	get(ts.URL + "/nodes/acagc")
+	get(ts.URL + "/nodes/ACAGC/neighbours")
+	get(ts.URL + "/nodes/AAAAA")
+	get(ts.URL + "/subgraph?kmer=ACAGC&radius=1&format=gfa")
+	get(ts.URL + "/locate?seq=CAGCTTCC")
+
+	post(ts.URL+"/samples?name=c", ">c\nGATTACA\n")
+
+	sopts.ReadOnly = false
+	sopts.MaxBody = 32
+	ws := httptest.NewServer(New(g, sopts).Handler())
+	defer ws.Close()
+	post(ws.URL+"/samples?name=b", ">b\nGATTACA\n")
+	post(ws.URL+"/samples?name=c", ">c\nGATTACAGATTACAGATTACAGATTACAGATTACA\n")
+	post(ws.URL+"/samples?name=c", ">c\nGATTACA\n")
+	get(ws.URL + "/samples")
+	// Output:
+	// 200 {"total":2,"offset":1,"limit":1,"items":["b"]}
+	// 200 {"total":18,"offset":0,"limit":2,"items":[{"uid":1,"sequence":"GATTA","samples":["a","b"]},{"uid":2,"sequence":"ATTAC","samples":["a","b"]}]}
+	// 200 {"uid":5,"sequence":"ACAGC","samples":["a","b"]}
+	// 200 {"forward":[{"uid":6,"sequence":"CAGCG","weight":1},{"uid":14,"sequence":"CAGCT","weight":1}],"reverse":[{"uid":4,"sequence":"TACAG","weight":2}]}
+	// 404 {"error":"k-mer AAAAA is not in the graph"}
+	// 200 H	VN:Z:1.0
+	// S	4	TACAG	LN:i:5	SC:i:2	RC:i:0
+	// S	5	ACAGC	LN:i:5	SC:i:2	RC:i:0
+	// S	6	CAGCG	LN:i:5	SC:i:1	RC:i:0
+	// S	14	CAGCT	LN:i:5	SC:i:1	RC:i:0
+	// L	4	+	5	+	4M	RC:i:2
+	// L	5	+	6	+	4M	RC:i:1
+	// L	5	+	14	+	4M	RC:i:1
+	// 200 {"kmers":4,"found":4,"coverage":1,"breaks":null,"samples":[{"sample":"b","identity":100,"stretches":[{"contig":"\u003eb","strand":"+","query":{"start":0,"end":8},"target":{"start":5,"end":13}}]}]}
+	// 403 {"error":"server is read-only"}
+	// 409 {"error":"sample b already exists"}
+	// 413 {"error":"genome is larger than 32 bytes"}
+	// 201 {"sample":"c"}
+	// 200 {"total":3,"offset":0,"limit":3,"items":["a","b","c"]}
+}
@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"log"
 	"os"
 	"path/filepath"
 )
@@ -14,7 +13,7 @@ func Walk(root string) ([]string, error) {
 		return nil
 	})
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return files, err
+	return files, nil
 }
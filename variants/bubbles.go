@@ -0,0 +1,329 @@
+// Package variants finds variant bubbles in the pangenome graph and reports
+// them against the samples which carry each allele.
+package variants
+
+import (
+	"sort"
+
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// Kind is the type of variant a bubble represents.
+type Kind int
+
+// Bubble kinds.
+const (
+	SNP Kind = iota
+	Indel
+	Complex
+)
+
+func (k Kind) String() string {
+	switch k {
+	case SNP:
+		return "snp"
+	case Indel:
+		return "indel"
+	case Complex:
+		return "complex"
+	}
+	return "unknown"
+}
+
+// Options bound the search so it stays tractable in repetitive regions.
+type Options struct {
+	MaxNodes   int // largest number of nodes inside a bubble.
+	MaxAlleles int // largest number of source to sink paths enumerated.
+}
+
+// DefaultOptions are big enough for bubbles made by variants a few hundred
+// bases long.
+var DefaultOptions = Options{
+	MaxNodes:   1000,
+	MaxAlleles: 16,
+}
+
+// Bubble is a superbubble: every path leaving Source reaches Sink, and no path
+// leaves the bubble in between. Alleles hold the sequence between the parts
+// shared by every allele; Prefix is the length of the shared start, counted
+// from the first base of the source k-mer.
+type Bubble struct {
+	Source  uint64
+	Sink    uint64
+	Kind    Kind
+	Prefix  int
+	Alleles []*Allele
+}
+
+// Allele is one path through a bubble. Weight is the smallest edge weight
+// along the path and Frequency is Weight relative to the other alleles.
+type Allele struct {
+	Path       []uint64
+	Sequence   string
+	Weight     int
+	Frequency  float64
+	Traversals []Traversal
+}
+
+// Traversal is a contig path which follows an allele. Offset is the position in
+// the contig of the source k-mer, which is also its index in the contig path.
+type Traversal struct {
+	Sample string
+	Contig string
+	Offset int
+}
+
+// Samples returns the distinct samples traversing an allele, in order.
+func (a *Allele) Samples() []string {
+	var samples []string
+	seen := make(map[string]bool)
+	for _, t := range a.Traversals {
+		if !seen[t.Sample] {
+			seen[t.Sample] = true
+			samples = append(samples, t.Sample)
+		}
+	}
+	return samples
+}
+
+// Nodes returns every node on the alleles of a bubble, including its source
+// and sink.
+func (b *Bubble) Nodes() []uint64 {
+	var nodes []uint64
+	seen := make(map[uint64]bool)
+	for _, a := range b.Alleles {
+		for _, uid := range a.Path {
+			if !seen[uid] {
+				seen[uid] = true
+				nodes = append(nodes, uid)
+			}
+		}
+	}
+	return nodes
+}
+
+// FindBubbles returns every superbubble of the index, ordered by source uid.
+func FindBubbles(idx *pangenome.Index, opts Options) []*Bubble {
+	var bubbles []*Bubble
+	for _, uid := range idx.Nodes() {
+		if idx.OutDegree(uid) < 2 {
+			continue
+		}
+		sink, inside, ok := superbubble(idx, uid, opts.MaxNodes)
+		if !ok {
+			continue
+		}
+		paths := allelePaths(idx, uid, sink, inside, opts.MaxAlleles)
+		if len(paths) < 2 {
+			continue
+		}
+		bubbles = append(bubbles, newBubble(idx, uid, sink, paths))
+	}
+	traverse(idx, bubbles)
+	return bubbles
+}
+
+// superbubble looks for the sink of the superbubble starting at source, as in
+// Onodera, Sadakane & Shibuya (2013). It fails on tips, on cycles back to the
+// source and when the bubble grows past maxNodes.
+func superbubble(idx *pangenome.Index, source uint64, maxNodes int) (uint64, map[uint64]bool, bool) {
+	visited := make(map[uint64]bool)
+	seen := make(map[uint64]bool)
+	stack := []uint64{source}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		visited[v] = true
+		delete(seen, v)
+		if len(visited) > maxNodes {
+			return 0, nil, false
+		}
+		if idx.OutDegree(v) == 0 {
+			return 0, nil, false
+		}
+		for u := range idx.Forward[v] {
+			if u == source {
+				return 0, nil, false
+			}
+			seen[u] = true
+			ready := true
+			for p := range idx.Reverse[u] {
+				if !visited[p] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				stack = append(stack, u)
+			}
+		}
+		if len(stack) == 1 && len(seen) == 1 {
+			t := stack[0]
+			if _, ok := seen[t]; !ok {
+				continue
+			}
+			if _, loop := idx.Forward[t][source]; loop {
+				return 0, nil, false
+			}
+			visited[t] = true
+			return t, visited, true
+		}
+	}
+	return 0, nil, false
+}
+
+// allelePaths enumerates up to max paths from source to sink through the
+// nodes of a bubble.
+func allelePaths(idx *pangenome.Index, source uint64, sink uint64, inside map[uint64]bool, max int) [][]uint64 {
+	var paths [][]uint64
+	var walk func(path []uint64)
+	walk = func(path []uint64) {
+		if len(paths) >= max {
+			return
+		}
+		last := path[len(path)-1]
+		if last == sink {
+			paths = append(paths, append([]uint64{}, path...))
+			return
+		}
+		next := make([]uint64, 0, len(idx.Forward[last]))
+		for u := range idx.Forward[last] {
+			if inside[u] {
+				next = append(next, u)
+			}
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+		for _, u := range next {
+			walk(append(path, u))
+		}
+	}
+	walk([]uint64{source})
+	return paths
+}
+
+func newBubble(idx *pangenome.Index, source uint64, sink uint64, paths [][]uint64) *Bubble {
+	b := &Bubble{
+		Source: source,
+		Sink:   sink,
+	}
+	seqs := make([]string, len(paths))
+	for i, path := range paths {
+		seqs[i] = idx.Spell(path)
+	}
+	prefix, suffix := sharedEnds(seqs)
+	b.Prefix = prefix
+
+	total := 0
+	for i, path := range paths {
+		a := &Allele{
+			Path:     path,
+			Sequence: seqs[i][prefix : len(seqs[i])-suffix],
+			Weight:   -1,
+		}
+		for j := 1; j < len(path); j++ {
+			w := idx.Forward[path[j-1]][path[j]]
+			if a.Weight == -1 || w < a.Weight {
+				a.Weight = w
+			}
+		}
+		total += a.Weight
+		b.Alleles = append(b.Alleles, a)
+	}
+	for _, a := range b.Alleles {
+		if total > 0 {
+			a.Frequency = float64(a.Weight) / float64(total)
+		}
+	}
+	b.Kind = classify(b.Alleles)
+	return b
+}
+
+// sharedEnds returns the length of the prefix and suffix shared by every
+// sequence, without letting them overlap in the shortest one.
+func sharedEnds(seqs []string) (int, int) {
+	shortest := len(seqs[0])
+	for _, s := range seqs {
+		if len(s) < shortest {
+			shortest = len(s)
+		}
+	}
+	prefix := 0
+	for prefix < shortest && sameAt(seqs, func(s string) byte { return s[prefix] }) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < shortest-prefix && sameAt(seqs, func(s string) byte { return s[len(s)-1-suffix] }) {
+		suffix++
+	}
+	return prefix, suffix
+}
+
+func sameAt(seqs []string, at func(string) byte) bool {
+	c := at(seqs[0])
+	for _, s := range seqs[1:] {
+		if at(s) != c {
+			return false
+		}
+	}
+	return true
+}
+
+// classify calls a bubble a SNP when every allele is a single base, an indel
+// when there are two alleles and one is empty, and complex otherwise.
+func classify(alleles []*Allele) Kind {
+	snp := true
+	for _, a := range alleles {
+		if len(a.Sequence) != 1 {
+			snp = false
+		}
+	}
+	if snp {
+		return SNP
+	}
+	if len(alleles) == 2 && (alleles[0].Sequence == "" || alleles[1].Sequence == "") {
+		return Indel
+	}
+	return Complex
+}
+
+// traverse records which contig paths follow each allele.
+func traverse(idx *pangenome.Index, bubbles []*Bubble) {
+	bySource := make(map[uint64]*Bubble, len(bubbles))
+	for _, b := range bubbles {
+		bySource[b.Source] = b
+	}
+	for s, sample := range idx.Samples {
+		for _, contig := range idx.Contigs[s] {
+			path := idx.Path(s, contig)
+			for i, uid := range path {
+				b, ok := bySource[uid]
+				if !ok {
+					continue
+				}
+				for _, a := range b.Alleles {
+					if follows(path[i:], a.Path) {
+						a.Traversals = append(a.Traversals, Traversal{
+							Sample: sample,
+							Contig: contig,
+							Offset: i,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// follows returns true if path starts with allele.
+func follows(path []uint64, allele []uint64) bool {
+	if len(path) < len(allele) {
+		return false
+	}
+	for i, uid := range allele {
+		if path[i] != uid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package variants
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+func ExampleFindBubbles() {
+	idx := pangenome.NewIndex(5)
+	idx.AddGenome("ref", []string{">ref"}, []string{"GATTACAGCGTCCATGGAACTTGCAAGTCGGATCCTAGA"})
+	idx.AddGenome("snp", []string{">snp"}, []string{"GATTACAGCTTCCATGGAACTTGCAAGTCGGATCCTAGA"})
+	idx.AddGenome("del", []string{">del"}, []string{"GATTACAGCGTCCATGGAACTTGCAAGTGGATCCTAGA"})
+
+	for _, b := range FindBubbles(idx, DefaultOptions) {
+		fmt.Println(b.Kind, b.Prefix)
+		for _, a := range b.Alleles {
+			fmt.Printf("%q %v %.2f\n", a.Sequence, a.Samples(), a.Frequency)
+		}
+	}
+	// Output:
+	// snp 5
+	// "G" [ref del] 0.67
+	// "T" [snp] 0.33
+	// indel 5
+	// "C" [ref snp] 0.67
+	// "" [del] 0.33
+}
+
+func ExampleWriteVCF() {
+	idx := pangenome.NewIndex(5)
+	idx.AddGenome("ref", []string{">chr1 test"}, []string{"GATTACAGCGTCCATGGAACTTGCAAGTCGGATCCTAGA"})
+	idx.AddGenome("snp", []string{">snp"}, []string{"GATTACAGCTTCCATGGAACTTGCAAGTCGGATCCTAGA"})
+	idx.AddGenome("del", []string{">del"}, []string{"GATTACAGCGTCCATGGAACTTGCAAGTGGATCCTAGA"})
+
+	bubbles := FindBubbles(idx, DefaultOptions)
+	WriteVCF(os.Stdout, idx, bubbles, "ref", DefaultVCFOptions)
+	// Output:
+	// ##fileformat=VCFv4.3
+	// ##source=prairiedog
+	// ##reference=ref
+	// ##contig=<ID=chr1,length=39>
+	// ##ALT=<ID=INS,Description="Insertion">
+	// ##INFO=<ID=TYPE,Number=1,Type=String,Description="Bubble type: snp, indel or complex">
+	// ##INFO=<ID=AF,Number=A,Type=Float,Description="Allele frequency from pangenome edge weights">
+	// ##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">
+	// ##INFO=<ID=SVLEN,Number=A,Type=Integer,Description="Length of each symbolic insertion">
+	// ##FORMAT=<ID=GT,Number=1,Type=String,Description="Haploid genotype">
+	// #CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	ref	snp	del
+	// chr1	10	.	G	T	.	.	TYPE=snp;AF=0.3333	GT	0	1	0
+	// chr1	28	.	TC	T	.	.	TYPE=indel;AF=0.3333	GT	0	0	1
+}
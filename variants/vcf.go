@@ -0,0 +1,194 @@
+package variants
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/superphy/prairiedog/pangenome"
+)
+
+// VCFOptions control how alleles are written.
+type VCFOptions struct {
+	// Insertions longer than this are written as the symbolic allele <INS>.
+	MaxInsertion int
+}
+
+// DefaultVCFOptions follow the usual 50bp cut-off for structural variants.
+var DefaultVCFOptions = VCFOptions{
+	MaxInsertion: 50,
+}
+
+// record is a single VCF line.
+type record struct {
+	contig    int // index of the contig in the reference.
+	chrom     string
+	pos       int // 1-based.
+	ref       string
+	alts      []string
+	svlens    []string
+	kind      Kind
+	freqs     []string
+	genotypes []string
+}
+
+// Chrom returns the VCF CHROM of a fasta header: its first word, without '>'.
+func Chrom(header string) string {
+	fields := strings.Fields(strings.TrimPrefix(header, ">"))
+	if len(fields) == 0 {
+		return header
+	}
+	return fields[0]
+}
+
+// WriteVCF writes the bubbles traversed by the reference sample as a VCF 4.3
+// file in the reference's contig coordinates, with one haploid genotype column
+// per sample. Bubbles the reference doesn't traverse can't be placed and are
+// skipped; a bubble the reference traverses more than once is written at each
+// position.
+func WriteVCF(w io.Writer, idx *pangenome.Index, bubbles []*Bubble, ref string, opts VCFOptions) error {
+	refIndex := -1
+	for i, sample := range idx.Samples {
+		if sample == ref {
+			refIndex = i
+		}
+	}
+	if refIndex == -1 {
+		return fmt.Errorf("reference sample %s not found", ref)
+	}
+	contigs := idx.Contigs[refIndex]
+	contigIndex := make(map[string]int, len(contigs))
+	for i, contig := range contigs {
+		contigIndex[contig] = i
+	}
+
+	var records []*record
+	for _, b := range bubbles {
+		for refAllele, a := range b.Alleles {
+			for _, t := range a.Traversals {
+				if t.Sample != ref {
+					continue
+				}
+				records = append(records, newRecord(idx, b, refAllele, t, contigIndex[t.Contig], opts))
+			}
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].contig != records[j].contig {
+			return records[i].contig < records[j].contig
+		}
+		return records[i].pos < records[j].pos
+	})
+
+	if err := writeVCFHeader(w, idx, refIndex); err != nil {
+		return err
+	}
+	for _, r := range records {
+		info := fmt.Sprintf("TYPE=%s;AF=%s", r.kind, strings.Join(r.freqs, ","))
+		if r.svlens != nil {
+			info += fmt.Sprintf(";SVTYPE=INS;SVLEN=%s", strings.Join(r.svlens, ","))
+		}
+		_, err := fmt.Fprintf(w, "%s\t%v\t.\t%s\t%s\t.\t.\t%s\tGT\t%s\n",
+			r.chrom, r.pos, r.ref, strings.Join(r.alts, ","), info, strings.Join(r.genotypes, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeVCFHeader(w io.Writer, idx *pangenome.Index, refIndex int) error {
+	ref := idx.Samples[refIndex]
+	lines := []string{
+		"##fileformat=VCFv4.3",
+		"##source=prairiedog",
+		fmt.Sprintf("##reference=%s", ref),
+	}
+	for _, contig := range idx.Contigs[refIndex] {
+		length := len(idx.Path(refIndex, contig)) + idx.K - 1
+		lines = append(lines, fmt.Sprintf("##contig=<ID=%s,length=%v>", Chrom(contig), length))
+	}
+	lines = append(lines,
+		`##ALT=<ID=INS,Description="Insertion">`,
+		`##INFO=<ID=TYPE,Number=1,Type=String,Description="Bubble type: snp, indel or complex">`,
+		`##INFO=<ID=AF,Number=A,Type=Float,Description="Allele frequency from pangenome edge weights">`,
+		`##INFO=<ID=SVTYPE,Number=1,Type=String,Description="Type of structural variant">`,
+		`##INFO=<ID=SVLEN,Number=A,Type=Integer,Description="Length of each symbolic insertion">`,
+		`##FORMAT=<ID=GT,Number=1,Type=String,Description="Haploid genotype">`,
+		"#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT\t"+strings.Join(idx.Samples, "\t"),
+	)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newRecord places a bubble at a traversal of the reference, with refAllele
+// as the REF allele and every other allele as an ALT.
+func newRecord(idx *pangenome.Index, b *Bubble, refAllele int, t Traversal, contig int, opts VCFOptions) *record {
+	r := &record{
+		contig: contig,
+		chrom:  Chrom(t.Contig),
+		pos:    t.Offset + b.Prefix + 1,
+		kind:   b.Kind,
+	}
+
+	// Indels need the base before the bubble as padding.
+	pad := ""
+	refSeq := b.Alleles[refAllele].Sequence
+	for _, a := range b.Alleles {
+		if a.Sequence == "" || len(a.Sequence) != len(refSeq) {
+			spelled := idx.Spell(b.Alleles[refAllele].Path)
+			pad = spelled[b.Prefix-1 : b.Prefix]
+			r.pos--
+			break
+		}
+	}
+	r.ref = pad + refSeq
+
+	// Alleles are renumbered so the reference is 0.
+	gt := map[int]string{refAllele: "0"}
+	symbolic := false
+	for i, a := range b.Alleles {
+		if i == refAllele {
+			continue
+		}
+		gt[i] = fmt.Sprint(len(r.alts) + 1)
+		alt := pad + a.Sequence
+		svlen := "."
+		if len(a.Sequence)-len(refSeq) > opts.MaxInsertion {
+			alt = "<INS>"
+			svlen = fmt.Sprint(len(a.Sequence) - len(refSeq))
+			symbolic = true
+		}
+		r.alts = append(r.alts, alt)
+		r.svlens = append(r.svlens, svlen)
+		r.freqs = append(r.freqs, fmt.Sprintf("%.4g", a.Frequency))
+	}
+	if !symbolic {
+		r.svlens = nil
+	}
+
+	calls := make(map[string]string, len(idx.Samples))
+	for i, a := range b.Alleles {
+		for _, sample := range a.Samples() {
+			if _, ok := calls[sample]; ok {
+				// Traverses more than one allele, e.g. a repeat.
+				calls[sample] = "."
+				continue
+			}
+			calls[sample] = gt[i]
+		}
+	}
+	for _, sample := range idx.Samples {
+		call, ok := calls[sample]
+		if !ok {
+			call = "."
+		}
+		r.genotypes = append(r.genotypes, call)
+	}
+	return r
+}